@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yyewolf/rodent/cache"
+)
+
+// parseCacheControl reads the cache control from the query of the request.
+func parseCacheControl(query url.Values) (cache.Control, error) {
+	var control cache.Control
+
+	maxAge, err := queryInt(query, "maxAge")
+	if err != nil {
+		return control, err
+	}
+
+	if maxAge < 0 {
+		return control, fmt.Errorf("maxAge must be positive")
+	}
+
+	control.MaxAge = time.Duration(maxAge) * time.Second
+
+	control.NoCache, err = queryBool(query, "noCache")
+	if err != nil {
+		return control, err
+	}
+
+	return control, nil
+}
+
+// matchesETag reports whether the If-None-Match header matches the entity tag,
+// using the weak comparison as required for GET and HEAD requests.
+func matchesETag(ifNoneMatch string, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
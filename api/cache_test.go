@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMatchesETag(t *testing.T) {
+	etag := `"9a0364b9e99bb480dd25e1f0284c8555"`
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"empty", "", false},
+		{"same", etag, true},
+		{"other", `"0000"`, false},
+		{"unquoted", `9a0364b9e99bb480dd25e1f0284c8555`, false},
+		{"weak", `W/` + etag, true},
+		{"list", `"0000", ` + etag, true},
+		{"list without spaces", `"0000",` + etag + `,"1111"`, true},
+		{"list with weak", `"0000", W/` + etag, true},
+		{"list without match", `"0000", "1111"`, false},
+		{"any", `*`, true},
+		{"any with spaces", ` * `, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchesETag(tt.ifNoneMatch, etag)
+			if got != tt.want {
+				t.Errorf("matchesETag(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		wantMaxAge  time.Duration
+		wantNoCache bool
+		wantErr     bool
+	}{
+		{"none", "", 0, false, false},
+		{"max age", "maxAge=60", time.Minute, false, false},
+		{"no cache", "noCache=true", 0, true, false},
+		{"negative max age", "maxAge=-1", 0, false, true},
+		{"invalid max age", "maxAge=soon", 0, false, true},
+		{"invalid no cache", "noCache=maybe", 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+
+			got, err := parseCacheControl(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCacheControl() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got.MaxAge != tt.wantMaxAge || got.NoCache != tt.wantNoCache {
+				t.Errorf("parseCacheControl() = %+v, want maxAge %v and noCache %v", got, tt.wantMaxAge, tt.wantNoCache)
+			}
+		})
+	}
+}
@@ -4,4 +4,9 @@ import "errors"
 
 var (
 	ErrCreatingMischiefInstance = errors.New("error creating Mischief instance")
+	ErrNotAcceptable            = errors.New("none of the accepted media types is supported")
+	ErrInvalidURL               = errors.New("invalid URL")
+	ErrInvalidURLScheme         = errors.New("invalid URL scheme")
+	ErrURLWithPort              = errors.New("URL should not contain a port")
+	ErrNoStorage                = errors.New("no storage is configured")
 )
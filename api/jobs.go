@@ -0,0 +1,130 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-fuego/fuego"
+	"github.com/go-fuego/fuego/option"
+	"github.com/yyewolf/rodent/jobs"
+	"github.com/yyewolf/rodent/mischief"
+	"github.com/yyewolf/rodent/webhook"
+)
+
+type JobsRepository struct {
+	mischief *mischief.Mischief
+	queue    *jobs.Queue
+	logger   *slog.Logger
+}
+
+func NewJobsRepository(mischief *mischief.Mischief, queue *jobs.Queue, logger *slog.Logger) *JobsRepository {
+	return &JobsRepository{
+		mischief: mischief,
+		queue:    queue,
+		logger:   logger,
+	}
+}
+
+func (j *JobsRepository) Group() string {
+	return "/jobs"
+}
+
+func (j *JobsRepository) Register(server *fuego.Server) {
+	fuego.Post(server, "", j.createJob,
+		option.Description("Enqueue a screenshot described by the JSON options provided in the body.\n\n"+
+			"The screenshot is taken asynchronously, poll the returned job for its status, "+
+			"or provide a callbackUrl notified with a signed payload when the job is finished."),
+		option.DefaultStatusCode(http.StatusAccepted),
+		option.AddError(http.StatusForbidden, "The callback URL is not reachable by the network policy"),
+		option.AddError(http.StatusServiceUnavailable, "The job queue is full"),
+	)
+
+	fuego.Get(server, "/{id}", j.getJob,
+		option.Description("Get the status of a job."),
+		option.Path("id", "Identifier of the job"),
+		option.AddError(http.StatusNotFound, "The job does not exist or has expired"),
+	)
+
+	fuego.GetStd(server, "/{id}/result", j.getJobResult,
+		optionReturnsImage,
+		option.Description("Get the screenshot of a succeeded job, or a redirection to the object it was written to when it requested to store it."),
+		option.Path("id", "Identifier of the job"),
+		option.AddError(http.StatusNotFound, "The job does not exist or has expired"),
+		option.AddError(http.StatusConflict, "The job is not finished or has failed"),
+	)
+}
+
+func (j *JobsRepository) createJob(c fuego.ContextWithBody[jobs.Request]) (jobs.Job, error) {
+	request, err := c.Body()
+	if err != nil {
+		return jobs.Job{}, err
+	}
+
+	status, err := validateTargets(j.mischief, &request.ScreenshotOptions)
+	if err != nil {
+		return jobs.Job{}, fuego.HTTPError{Err: err, Status: status, Detail: err.Error()}
+	}
+
+	job, err := j.queue.Enqueue(c.Context(), request)
+	if err != nil {
+		if mischief.IsInvalidOptionsError(err) || errors.Is(err, webhook.ErrInvalidCallbackURL) || errors.Is(err, jobs.ErrNoCallbacks) || errors.Is(err, jobs.ErrNoStorage) {
+			return jobs.Job{}, fuego.BadRequestError{Err: err, Detail: err.Error()}
+		}
+
+		if errors.Is(err, mischief.ErrBlockedByNetworkPolicy) {
+			// The details, such as resolved addresses, are not disclosed to the client
+			j.logger.Warn("callback blocked by the network policy", slog.Any("error", err))
+			return jobs.Job{}, fuego.ForbiddenError{Err: err, Detail: mischief.ErrBlockedByNetworkPolicy.Error()}
+		}
+
+		if errors.Is(err, jobs.ErrQueueFull) || errors.Is(err, jobs.ErrQueueStopped) {
+			return jobs.Job{}, fuego.HTTPError{Err: err, Status: http.StatusServiceUnavailable, Detail: err.Error()}
+		}
+
+		j.logger.Error("error while enqueuing job", slog.Any("error", err))
+		return jobs.Job{}, err
+	}
+
+	c.Response().Header().Set("Location", c.Request().URL.Path+"/"+job.ID)
+
+	return job, nil
+}
+
+func (j *JobsRepository) getJob(c fuego.ContextNoBody) (jobs.Job, error) {
+	job, err := j.queue.Get(c.PathParam("id"))
+	if err != nil {
+		return jobs.Job{}, fuego.NotFoundError{Err: err, Detail: err.Error()}
+	}
+
+	return job, nil
+}
+
+func (j *JobsRepository) getJobResult(writer http.ResponseWriter, req *http.Request) {
+	job, err := j.queue.Get(req.PathValue("id"))
+	if err != nil {
+		fuego.SendJSONError(writer, req, fuego.NotFoundError{Err: err, Detail: err.Error()})
+		return
+	}
+
+	// Stored results are fetched from the storage
+	if job.Object != nil {
+		http.Redirect(writer, req, job.Object.URL, http.StatusSeeOther)
+		return
+	}
+
+	bytes, err := job.Result()
+	if err != nil {
+		fuego.SendJSONError(writer, req, fuego.ConflictError{Err: err, Detail: err.Error()})
+		return
+	}
+
+	writer.Header().Set("Content-Type", job.Options().Format.ContentType())
+	_, err = writer.Write(bytes)
+	if err != nil {
+		j.logger.Error("error while writing response", slog.Any("error", err))
+		return
+	}
+}
+
+var _ Repository = &JobsRepository{}
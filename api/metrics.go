@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yyewolf/rodent/metrics"
+)
+
+// statusRecorder records the status code written to the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// metricsMiddleware counts and times the requests by route.
+//
+// It runs after routing, the route is the pattern of the request
+// so that the cardinality of the metrics stays bounded.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, req)
+
+		metrics.HTTPRequests.WithLabelValues(req.Pattern, req.Method, strconv.Itoa(recorder.status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(req.Pattern, req.Method).Observe(time.Since(start).Seconds())
+	})
+}
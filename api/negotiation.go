@@ -0,0 +1,72 @@
+package api
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// acceptedType is a media range of an Accept header with its weight.
+type acceptedType struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept parses an Accept header, ordered by decreasing preference.
+func parseAccept(accept string) []acceptedType {
+	var types []acceptedType
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		if quality <= 0 {
+			continue
+		}
+
+		types = append(types, acceptedType{mediaType: mediaType, quality: quality})
+	}
+
+	sort.SliceStable(types, func(i, j int) bool {
+		return types[i].quality > types[j].quality
+	})
+
+	return types
+}
+
+// negotiateFormat picks the screenshot format preferred by the Accept header.
+//
+// An empty header, "*/*" or "image/*" negotiates to PNG.
+// It returns false when none of the supported formats is acceptable.
+func negotiateFormat(accept string) (mischief.Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return mischief.FormatPNG, true
+	}
+
+	for _, accepted := range parseAccept(accept) {
+		if accepted.mediaType == "*/*" || accepted.mediaType == "image/*" {
+			return mischief.FormatPNG, true
+		}
+
+		for _, format := range mischief.Formats {
+			if accepted.mediaType == format.ContentType() {
+				return format, true
+			}
+		}
+	}
+
+	return "", false
+}
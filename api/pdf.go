@@ -0,0 +1,228 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-fuego/fuego"
+	"github.com/go-fuego/fuego/option"
+	"github.com/go-fuego/fuego/param"
+	"github.com/yyewolf/rodent/mischief"
+)
+
+type PDFRepository struct {
+	mischief *mischief.Mischief
+	logger   *slog.Logger
+}
+
+func NewPDFRepository(mischief *mischief.Mischief, logger *slog.Logger) *PDFRepository {
+	return &PDFRepository{
+		mischief: mischief,
+		logger:   logger,
+	}
+}
+
+func (p *PDFRepository) Group() string {
+	return "/pdf"
+}
+
+var optionReturnsPDF = func(br *fuego.BaseRoute) {
+	response := openapi3.NewResponse()
+	response.WithDescription("Generated document")
+	response.WithContent(openapi3.NewContentWithSchema(nil, []string{"application/pdf"}))
+	br.Operation.AddResponse(200, response)
+}
+
+func (p *PDFRepository) Register(server *fuego.Server) {
+	fuego.GetStd(server, "", p.renderPDF,
+		optionReturnsPDF,
+		option.Description("Render the provided url as a PDF document."),
+		option.Query("url", "The website to render", param.Example("example", "https://google.com")),
+		option.Query("paper", "Named paper size (letter, legal, tabloid, ledger, a0 to a6)", param.Example("a4", "a4")),
+		option.Query("paperWidth", "Paper width in inches, overrides paper", param.Example("a4", "8.27")),
+		option.Query("paperHeight", "Paper height in inches, overrides paper", param.Example("a4", "11.7")),
+		option.Query("marginTop", "Top margin in inches", param.Example("one inch", "1")),
+		option.Query("marginRight", "Right margin in inches", param.Example("one inch", "1")),
+		option.Query("marginBottom", "Bottom margin in inches", param.Example("one inch", "1")),
+		option.Query("marginLeft", "Left margin in inches", param.Example("one inch", "1")),
+		option.QueryBool("landscape", "Print the pages in landscape orientation"),
+		option.QueryBool("printBackground", "Print the background graphics"),
+		option.Query("headerTemplate", "HTML template of the header of every page"),
+		option.Query("footerTemplate", "HTML template of the footer of every page"),
+	)
+}
+
+// parsePaperSize reads the paper size query parameters of the request.
+func parsePaperSize(query url.Values) (mischief.PaperSize, error) {
+	var size mischief.PaperSize
+	var err error
+
+	if query.Get("paper") != "" {
+		size, err = mischief.ParsePaperSize(query.Get("paper"))
+		if err != nil {
+			return size, err
+		}
+	}
+
+	width, err := queryFloat(query, "paperWidth")
+	if err != nil {
+		return size, err
+	}
+
+	height, err := queryFloat(query, "paperHeight")
+	if err != nil {
+		return size, err
+	}
+
+	if width != 0 || height != 0 {
+		size = mischief.PaperSize{Width: width, Height: height}
+	}
+
+	return size, nil
+}
+
+// parseMargins reads the margins query parameters of the request.
+//
+// It returns nil when no margin is set.
+func parseMargins(query url.Values) (*mischief.Margins, error) {
+	var margins mischief.Margins
+	var err error
+
+	if !query.Has("marginTop") && !query.Has("marginRight") && !query.Has("marginBottom") && !query.Has("marginLeft") {
+		return nil, nil
+	}
+
+	margins.Top, err = queryFloat(query, "marginTop")
+	if err != nil {
+		return nil, err
+	}
+
+	margins.Right, err = queryFloat(query, "marginRight")
+	if err != nil {
+		return nil, err
+	}
+
+	margins.Bottom, err = queryFloat(query, "marginBottom")
+	if err != nil {
+		return nil, err
+	}
+
+	margins.Left, err = queryFloat(query, "marginLeft")
+	if err != nil {
+		return nil, err
+	}
+
+	return &margins, nil
+}
+
+// parsePDFOptions reads the PDF options of the request.
+func parsePDFOptions(query url.Values) ([]mischief.PDFOpt, error) {
+	var opts []mischief.PDFOpt
+
+	size, err := parsePaperSize(query)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, mischief.WithPaperSize(size))
+
+	margins, err := parseMargins(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if margins != nil {
+		opts = append(opts, mischief.WithMargins(*margins))
+	}
+
+	landscape, err := queryBool(query, "landscape")
+	if err != nil {
+		return nil, err
+	}
+
+	if landscape {
+		opts = append(opts, mischief.WithLandscape())
+	}
+
+	printBackground, err := queryBool(query, "printBackground")
+	if err != nil {
+		return nil, err
+	}
+
+	if printBackground {
+		opts = append(opts, mischief.WithPrintBackground())
+	}
+
+	opts = append(opts, mischief.WithHeaderFooter(query.Get("headerTemplate"), query.Get("footerTemplate")))
+
+	return opts, nil
+}
+
+func (p *PDFRepository) renderPDF(writer http.ResponseWriter, req *http.Request) {
+	parsedUrl, err := validateTargetURL(req.URL.Query().Get("url"))
+	if err != nil {
+		p.logger.Error("error while parsing URL", slog.Any("error", err))
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Rejected before waiting for a browser
+	err = p.mischief.CheckDomain(parsedUrl.Hostname())
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	opts, err := parsePDFOptions(req.URL.Query())
+	if err != nil {
+		p.logger.Error("error while parsing pdf options", slog.Any("error", err))
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bytes, err := p.mischief.RenderPDF(req.Context(), parsedUrl.String(), opts...)
+	if err != nil {
+		if req.Context().Err() != nil {
+			p.logger.Info("pdf cancelled by the client", slog.Any("error", err))
+			return
+		}
+
+		if errors.Is(err, mischief.ErrDomainNotAllowed) {
+			http.Error(writer, mischief.ErrDomainNotAllowed.Error(), http.StatusForbidden)
+			return
+		}
+
+		if errors.Is(err, mischief.ErrBlockedByNetworkPolicy) {
+			// The details, such as resolved addresses, are not disclosed to the client
+			p.logger.Warn("target blocked by the network policy", slog.Any("error", err))
+			http.Error(writer, mischief.ErrBlockedByNetworkPolicy.Error(), http.StatusForbidden)
+			return
+		}
+
+		if errors.Is(err, mischief.ErrGettingBrowser) {
+			http.Error(writer, "error while getting browser", http.StatusRequestTimeout)
+			return
+		}
+
+		if errors.Is(err, mischief.ErrInvalidPaperSize) || errors.Is(err, mischief.ErrInvalidMargins) {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		p.logger.Error("error while rendering pdf", slog.Any("error", err))
+		http.Error(writer, "error while rendering pdf", http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/pdf")
+	_, err = writer.Write(bytes)
+	if err != nil {
+		p.logger.Error("error while writing response", slog.Any("error", err))
+		http.Error(writer, "error while writing response", http.StatusInternalServerError)
+		return
+	}
+}
+
+var _ Repository = &PDFRepository{}
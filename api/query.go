@@ -0,0 +1,67 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// queryInt parses an optional integer query parameter.
+func queryInt(query url.Values, key string) (int, error) {
+	value := query.Get(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", key)
+	}
+
+	return i, nil
+}
+
+// queryFloat parses an optional float query parameter.
+func queryFloat(query url.Values, key string) (float64, error) {
+	value := query.Get(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", key)
+	}
+
+	return f, nil
+}
+
+// queryBool parses an optional boolean query parameter.
+func queryBool(query url.Values, key string) (bool, error) {
+	value := query.Get(key)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", key)
+	}
+
+	return b, nil
+}
+
+// queryList parses an optional comma separated query parameter.
+func queryList(query url.Values, key string) []string {
+	var values []string
+
+	for _, value := range strings.Split(query.Get(key), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -1,27 +1,46 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-fuego/fuego"
 	"github.com/go-fuego/fuego/option"
 	"github.com/go-fuego/fuego/param"
+	"github.com/yyewolf/rodent/cache"
 	"github.com/yyewolf/rodent/mischief"
+	"github.com/yyewolf/rodent/storage"
 )
 
 type ScreenshotRepository struct {
-	mischief *mischief.Mischief
-	logger   *slog.Logger
+	mischief  *mischief.Mischief
+	coalescer *cache.Coalescer
+	cache     *cache.Cache
+	storage   storage.Storage
+	logger    *slog.Logger
+
+	// maxBodySize is the maximum size of the HTML document of a request
+	maxBodySize int64
 }
 
-func NewScreenshotRepository(mischief *mischief.Mischief, logger *slog.Logger) *ScreenshotRepository {
+func NewScreenshotRepository(mischief *mischief.Mischief, coalescer *cache.Coalescer, cache *cache.Cache, storage storage.Storage, logger *slog.Logger, maxBodySize int64) *ScreenshotRepository {
 	return &ScreenshotRepository{
-		mischief: mischief,
-		logger:   logger,
+		mischief:    mischief,
+		coalescer:   coalescer,
+		cache:       cache,
+		storage:     storage,
+		logger:      logger,
+		maxBodySize: maxBodySize,
 	}
 }
 
@@ -29,59 +48,412 @@ func (s *ScreenshotRepository) Group() string {
 	return "/screenshot"
 }
 
-var optionReturnsPNG = func(br *fuego.BaseRoute) {
+var optionReturnsImage = func(br *fuego.BaseRoute) {
+	var contentTypes []string
+	for _, format := range mischief.Formats {
+		contentTypes = append(contentTypes, format.ContentType())
+	}
+
 	response := openapi3.NewResponse()
 	response.WithDescription("Generated image")
-	response.WithContent(openapi3.NewContentWithSchema(nil, []string{"image/png"}))
+	response.WithContent(openapi3.NewContentWithSchema(nil, contentTypes))
 	br.Operation.AddResponse(200, response)
 }
 
+// optionReturnsStoredObject documents the stored object returned instead of the image with store=true.
+var optionReturnsStoredObject = func(br *fuego.BaseRoute) {
+	schema := fuego.SchemaTagFromType(br.OpenAPI, storage.Object{})
+
+	response := br.Operation.Responses.Value("200").Value
+	response.WithDescription("Generated image, or the stored object with store=true")
+	response.Content["application/json"] = openapi3.NewMediaType().WithSchemaRef(&schema.SchemaRef)
+}
+
+// optionNotModified documents the conditional requests answered from the entity tag of the image.
+var optionNotModified = func(br *fuego.BaseRoute) {
+	option.Header("If-None-Match", "Entity tags of a cached image, answered with a 304 when it did not change")(br)
+	br.Operation.AddResponse(http.StatusNotModified, openapi3.NewResponse().WithDescription("The image matches the If-None-Match header"))
+}
+
+// optionScreenshotQuery declares the query parameters shared by the screenshot routes.
+var optionScreenshotQuery = option.Group(
+	option.QueryInt("width", "Width of the viewport in CSS pixels", param.Example("desktop", 1920)),
+	option.QueryInt("height", "Height of the viewport in CSS pixels", param.Example("desktop", 1080)),
+	option.Query("deviceScaleFactor", "Device pixel ratio of the viewport", param.Example("retina", "2")),
+	option.QueryBool("mobile", "Emulate a mobile device"),
+	option.QueryBool("touch", "Enable touch events emulation"),
+	option.QueryBool("fullPage", "Capture the whole scrollable document instead of the viewport"),
+	option.Query("selector", "CSS selector of the element to capture instead of the viewport", param.Example("element", "#main")),
+	option.Query("format", "Image format (png, jpeg or webp), negotiated from the Accept header when omitted", param.Example("jpeg", "jpeg")),
+	option.QueryInt("quality", "Compression quality (1-100) for jpeg and webp", param.Example("thumbnail", 80)),
+	option.QueryBool("blockAds", "Block the requests to known ad and tracker domains"),
+	option.Query("blockResourceTypes", "Comma separated types of resources blocked (image, media, font or stylesheet)", param.Example("lightweight", "font,media")),
+	option.Query("blockUrlPattern", "Pattern of URLs blocked, * matching any characters, can be repeated", param.Example("ads", "*://*.example.com/ads/*")),
+	option.QueryBool("hideCookieBanners", "Accept and hide the cookie banners of common consent management platforms"),
+	option.Query("hideSelector", "CSS selector of elements hidden before capturing, can be repeated", param.Example("popup", ".newsletter-popup")),
+	option.QueryBool("store", "Write the image to the configured storage and return the stored object instead of the image"),
+	option.QueryInt("maxAge", "Maximum age in seconds of a cached screenshot, the cache TTL when omitted", param.Example("hourly", 3600)),
+	option.QueryBool("noCache", "Take a new screenshot instead of a cached one"),
+	option.Query("waitUntil", "Wait for the page to be loaded (load), the network to be idle (networkIdle) or the DOM to be stable (domStable)", param.Example("spa", "networkIdle")),
+	option.Query("waitForSelector", "CSS selector of an element to wait for before capturing", param.Example("element", "#main")),
+	option.Query("waitForExpression", "JavaScript expression to wait to be truthy before capturing", param.Example("flag", "window.ready === true")),
+	option.QueryInt("delay", "Delay in milliseconds to wait before capturing", param.Example("animation", 500)),
+)
+
 func (s *ScreenshotRepository) Register(server *fuego.Server) {
 	fuego.GetStd(server, "", s.takeScreenshot,
-		optionReturnsPNG,
+		optionReturnsImage,
+		optionReturnsStoredObject,
+		optionNotModified,
 		option.Description("Take a screenshot of the provided url."),
 		option.Query("url", "The website to take a screenshot of", param.Example("example", "https://google.com")),
+		optionScreenshotQuery,
 	)
+
+	route := fuego.Post(server, "", s.postScreenshot,
+		optionReturnsImage,
+		optionReturnsStoredObject,
+		optionNotModified,
+		option.Description("Take a screenshot described by the JSON options provided in the body.\n\n"+
+			"An HTML document can also be sent with the text/html content type, the options are then read from the query."),
+		option.RequestContentType("application/json", "text/html"),
+		option.AddResponse(http.StatusUnprocessableEntity, "A script or a step of the options failed", fuego.Response{
+			Type:         fuego.HTTPError{},
+			ContentTypes: []string{"application/problem+json"},
+		}),
+		option.Query("baseUrl", "URL against which relative URLs of a text/html document are resolved", param.Example("example", "https://google.com")),
+		optionScreenshotQuery,
+	)
+
+	// The HTML document is sent as is, not as a serialized ScreenshotOptions
+	route.Operation.RequestBody.Value.Content["text/html"] = openapi3.NewMediaType().WithSchema(openapi3.NewStringSchema())
+}
+
+// parseViewport reads the viewport query parameters of the request.
+func parseViewport(query url.Values) (mischief.Viewport, error) {
+	var viewport mischief.Viewport
+	var err error
+
+	viewport.Width, err = queryInt(query, "width")
+	if err != nil {
+		return viewport, err
+	}
+
+	viewport.Height, err = queryInt(query, "height")
+	if err != nil {
+		return viewport, err
+	}
+
+	viewport.DeviceScaleFactor, err = queryFloat(query, "deviceScaleFactor")
+	if err != nil {
+		return viewport, err
+	}
+
+	viewport.Mobile, err = queryBool(query, "mobile")
+	if err != nil {
+		return viewport, err
+	}
+
+	viewport.Touch, err = queryBool(query, "touch")
+	if err != nil {
+		return viewport, err
+	}
+
+	return viewport, nil
+}
+
+// parseScreenshotQuery reads the screenshot options from the query of the request.
+func parseScreenshotQuery(query url.Values) (mischief.ScreenshotOptions, error) {
+	var options mischief.ScreenshotOptions
+	var err error
+
+	options.Viewport, err = parseViewport(query)
+	if err != nil {
+		return options, err
+	}
+
+	options.FullPage, err = queryBool(query, "fullPage")
+	if err != nil {
+		return options, err
+	}
+
+	options.Selector = query.Get("selector")
+
+	if query.Get("format") != "" {
+		options.Format, err = mischief.ParseFormat(query.Get("format"))
+		if err != nil {
+			return options, err
+		}
+	}
+
+	options.Quality, err = queryInt(query, "quality")
+	if err != nil {
+		return options, err
+	}
+
+	options.HideCookieBanners, err = queryBool(query, "hideCookieBanners")
+	if err != nil {
+		return options, err
+	}
+
+	options.HideSelectors = query["hideSelector"]
+
+	options.Blocking, err = parseResourceBlocking(query)
+	if err != nil {
+		return options, err
+	}
+
+	options.Waits, err = parseWaits(query)
+	if err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+// parseResourceBlocking reads the resource blocking from the query of the request.
+//
+// It is nil, falling back to the server default, when none of its parameters is set.
+func parseResourceBlocking(query url.Values) (*mischief.ResourceBlocking, error) {
+	if !query.Has("blockAds") && !query.Has("blockResourceTypes") && !query.Has("blockUrlPattern") {
+		return nil, nil
+	}
+
+	var blocking mischief.ResourceBlocking
+	var err error
+
+	blocking.Ads, err = queryBool(query, "blockAds")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resourceType := range queryList(query, "blockResourceTypes") {
+		blocking.ResourceTypes = append(blocking.ResourceTypes, mischief.ResourceType(resourceType))
+	}
+
+	blocking.URLPatterns = query["blockUrlPattern"]
+
+	return &blocking, nil
+}
+
+// parseWaits reads the waits from the query of the request.
+//
+// They are run in a fixed order: waitUntil, waitForSelector, waitForExpression then delay.
+func parseWaits(query url.Values) ([]mischief.Wait, error) {
+	var waits []mischief.Wait
+
+	if waitUntil := query.Get("waitUntil"); waitUntil != "" {
+		waits = append(waits, mischief.Wait{Type: mischief.WaitType(waitUntil)})
+	}
+
+	if selector := query.Get("waitForSelector"); selector != "" {
+		waits = append(waits, mischief.Wait{Type: mischief.WaitSelector, Selector: selector})
+	}
+
+	if expression := query.Get("waitForExpression"); expression != "" {
+		waits = append(waits, mischief.Wait{Type: mischief.WaitExpression, Expression: expression})
+	}
+
+	delay, err := queryInt(query, "delay")
+	if err != nil {
+		return nil, err
+	}
+
+	if delay > 0 {
+		waits = append(waits, mischief.Wait{Type: mischief.WaitDelay, Duration: delay})
+	}
+
+	return waits, nil
 }
 
 func (s *ScreenshotRepository) takeScreenshot(writer http.ResponseWriter, req *http.Request) {
-	unsafeUrl := req.URL.Query().Get("url")
+	options, err := parseScreenshotQuery(req.URL.Query())
+	if err != nil {
+		s.logger.Error("error while parsing screenshot options", slog.Any("error", err))
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	parsedUrl, err := url.Parse(unsafeUrl)
+	options.URL = req.URL.Query().Get("url")
+
+	s.screenshot(writer, req, options)
+}
+
+func (s *ScreenshotRepository) postScreenshot(c fuego.ContextWithBody[mischief.ScreenshotOptions]) (any, error) {
+	writer, req := c.Response(), c.Request()
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType != "text/html" {
+		options, err := c.Body()
+		if err != nil {
+			return nil, err
+		}
+
+		s.screenshot(writer, req, options)
+		return nil, nil
+	}
+
+	options, err := parseScreenshotQuery(req.URL.Query())
+	if err != nil {
+		s.logger.Error("error while parsing screenshot options", slog.Any("error", err))
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return nil, nil
+	}
+
+	document, err := io.ReadAll(http.MaxBytesReader(writer, req.Body, s.maxBodySize))
 	if err != nil {
-		s.logger.Error("error while parsing URL", slog.Any("error", err))
-		http.Error(writer, "invalid URL", http.StatusBadRequest)
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			http.Error(writer, "document is too large", http.StatusRequestEntityTooLarge)
+			return nil, nil
+		}
+
+		s.logger.Error("error while reading document", slog.Any("error", err))
+		http.Error(writer, "error while reading document", http.StatusBadRequest)
+		return nil, nil
+	}
+
+	options.HTML = string(document)
+	options.BaseURL = req.URL.Query().Get("baseUrl")
+
+	s.screenshot(writer, req, options)
+	return nil, nil
+}
+
+// screenshot validates the options, takes the screenshot and writes it.
+func (s *ScreenshotRepository) screenshot(writer http.ResponseWriter, req *http.Request, options mischief.ScreenshotOptions) {
+	store, err := queryBool(req.URL.Query(), "store")
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if store && s.storage == nil {
+		http.Error(writer, ErrNoStorage.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https" {
-		err := errors.New("invalid URL scheme")
-		s.logger.Error("error while parsing URL", slog.Any("error", err))
-		http.Error(writer, "invalid URL scheme", http.StatusBadRequest)
+	control, err := parseCacheControl(req.URL.Query())
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if parsedUrl.Port() != "" {
-		err := errors.New("URL should not contain a port")
-		s.logger.Error("error while parsing URL", slog.Any("error", err))
-		http.Error(writer, "URL should not contain a port", http.StatusBadRequest)
+	status, err := validateTargets(s.mischief, &options)
+	if err != nil {
+		s.logger.Error("error while validating URL", slog.Any("error", err))
+		http.Error(writer, err.Error(), status)
 		return
 	}
 
-	bytes, err := s.mischief.TakeScreenshot(parsedUrl.String())
+	// The stored object is returned as JSON, the format is not negotiated
+	if options.Format == "" && !store {
+		format, ok := negotiateFormat(req.Header.Get("Accept"))
+		if !ok {
+			http.Error(writer, ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+			return
+		}
+
+		options.Format = format
+	}
+
+	// Cached screenshots are answered without taking a browser, so are the conditional requests matching them
+	var entry cache.Entry
+	cached := false
+	if s.cache != nil {
+		entry, cached = s.cache.Lookup(options, control)
+	}
+
+	if !cached {
+		entry, cached, err = s.capture(req.Context(), options, control)
+	}
 	if err != nil {
+		if req.Context().Err() != nil {
+			s.logger.Info("screenshot cancelled by the client", slog.Any("error", err))
+			return
+		}
+
+		if errors.Is(err, mischief.ErrDomainNotAllowed) {
+			http.Error(writer, mischief.ErrDomainNotAllowed.Error(), http.StatusForbidden)
+			return
+		}
+
+		if errors.Is(err, mischief.ErrBlockedByNetworkPolicy) {
+			// The details, such as resolved addresses, are not disclosed to the client
+			s.logger.Warn("target blocked by the network policy", slog.Any("error", err))
+			http.Error(writer, mischief.ErrBlockedByNetworkPolicy.Error(), http.StatusForbidden)
+			return
+		}
+
 		if errors.Is(err, mischief.ErrGettingBrowser) {
 			http.Error(writer, "error while getting browser", http.StatusRequestTimeout)
 			return
 		}
 
+		if mischief.IsInvalidOptionsError(err) {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, mischief.ErrWaitingForElement) {
+			http.Error(writer, "selector did not match any visible element", http.StatusUnprocessableEntity)
+			return
+		}
+
+		var scriptError *mischief.ScriptError
+		if errors.As(err, &scriptError) {
+			s.logger.Warn("error while evaluating script", slog.Any("error", scriptError))
+			fuego.SendJSONError(writer, req, scriptProblem(scriptError))
+			return
+		}
+
+		var stepError *mischief.StepError
+		if errors.As(err, &stepError) {
+			s.logger.Warn("error while running step", slog.Any("error", stepError))
+			fuego.SendJSONError(writer, req, stepProblem(stepError))
+			return
+		}
+
+		var waitError *mischief.WaitError
+		if errors.As(err, &waitError) {
+			s.logger.Warn("error while waiting for page", slog.Any("error", waitError))
+			http.Error(writer, waitError.Error(), http.StatusGatewayTimeout)
+			return
+		}
+
 		s.logger.Error("error while taking screenshot", slog.Any("error", err))
 		http.Error(writer, "error while taking screenshot", http.StatusInternalServerError)
 		return
 	}
 
-	writer.Header().Set("Content-Type", "image/png")
-	_, err = writer.Write(bytes)
+	// The steps of a cached screenshot did not run for this request
+	if !cached && len(entry.Steps) > 0 {
+		writer.Header().Set("Server-Timing", serverTiming(entry.Steps))
+	}
+
+	if store {
+		s.store(writer, req, options.Format, entry.Data)
+		return
+	}
+
+	if s.cache != nil {
+		writer.Header().Set("X-Cache", "MISS")
+		if cached {
+			writer.Header().Set("X-Cache", "HIT")
+			writer.Header().Set("Age", strconv.Itoa(int(entry.Age().Seconds())))
+		}
+	}
+
+	writer.Header().Set("ETag", entry.ETag)
+	writer.Header().Add("Vary", "Accept")
+
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" && matchesETag(ifNoneMatch, entry.ETag) {
+		writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writer.Header().Set("Content-Type", entry.ContentType)
+	_, err = writer.Write(entry.Data)
 	if err != nil {
 		s.logger.Error("error while writing response", slog.Any("error", err))
 		http.Error(writer, "error while writing response", http.StatusInternalServerError)
@@ -89,4 +461,113 @@ func (s *ScreenshotRepository) takeScreenshot(writer http.ResponseWriter, req *h
 	}
 }
 
+// capture takes the screenshot, through the cache or the coalescer when they are enabled.
+// It reports whether the screenshot was cached.
+func (s *ScreenshotRepository) capture(ctx context.Context, options mischief.ScreenshotOptions, control cache.Control) (cache.Entry, bool, error) {
+	if s.cache != nil {
+		return s.cache.TakeScreenshot(ctx, options, control)
+	}
+
+	var screenshot mischief.Screenshot
+	var err error
+	if s.coalescer != nil {
+		screenshot, _, err = s.coalescer.Capture(ctx, options)
+	} else {
+		screenshot, err = s.mischief.Capture(ctx, options)
+	}
+	if err != nil {
+		return cache.Entry{}, false, err
+	}
+
+	entry := cache.NewEntry(screenshot.Data, options.Format.ContentType())
+	entry.Steps = screenshot.Steps
+
+	return entry, false, nil
+}
+
+// store writes the screenshot to the storage and writes the stored object.
+func (s *ScreenshotRepository) store(writer http.ResponseWriter, req *http.Request, format mischief.Format, bytes []byte) {
+	key, err := storage.NewKey(format.Extension())
+	if err != nil {
+		s.logger.Error("error while generating object key", slog.Any("error", err))
+		http.Error(writer, "error while storing screenshot", http.StatusInternalServerError)
+		return
+	}
+
+	object, err := s.storage.Put(req.Context(), key, bytes, format.ContentType())
+	if err != nil {
+		s.logger.Error("error while storing screenshot", slog.Any("error", err))
+		http.Error(writer, "error while storing screenshot", http.StatusBadGateway)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(writer).Encode(object)
+	if err != nil {
+		s.logger.Error("error while writing response", slog.Any("error", err))
+		return
+	}
+}
+
+// scriptProblem describes a failed script as problem details,
+// locating the exception thrown in the script.
+func scriptProblem(scriptError *mischief.ScriptError) fuego.HTTPError {
+	status := http.StatusUnprocessableEntity
+	if scriptError.Timeout {
+		status = http.StatusGatewayTimeout
+	}
+
+	more := map[string]any{
+		"timeout": scriptError.Timeout,
+	}
+
+	if scriptError.Line > 0 {
+		more["line"] = scriptError.Line
+		more["column"] = scriptError.Column
+	}
+
+	return fuego.HTTPError{
+		Title:  "script failed",
+		Status: status,
+		Detail: scriptError.Error(),
+		Errors: []fuego.ErrorItem{{
+			Name:   scriptError.Option,
+			Reason: scriptError.Message,
+			More:   more,
+		}},
+	}
+}
+
+// stepProblem describes a failed step as problem details,
+// naming the step by its index.
+func stepProblem(stepError *mischief.StepError) fuego.HTTPError {
+	return fuego.HTTPError{
+		Title:  "step failed",
+		Status: http.StatusUnprocessableEntity,
+		Detail: stepError.Error(),
+		Errors: []fuego.ErrorItem{{
+			Name:   fmt.Sprintf("steps[%d]", stepError.Index),
+			Reason: stepError.Err.Error(),
+			More: map[string]any{
+				"index":    stepError.Index,
+				"type":     stepError.Type,
+				"duration": stepError.Duration.Milliseconds(),
+			},
+		}},
+	}
+}
+
+// serverTiming formats the results of the steps as the value of
+// a Server-Timing header, one metric per step named by its index.
+func serverTiming(steps []mischief.StepResult) string {
+	timings := make([]string, 0, len(steps))
+
+	for _, step := range steps {
+		duration := float64(step.Duration.Microseconds()) / 1000
+		timings = append(timings, fmt.Sprintf(`step-%d;desc="%s";dur=%.1f`, step.Index, step.Type, duration))
+	}
+
+	return strings.Join(timings, ", ")
+}
+
 var _ Repository = &ScreenshotRepository{}
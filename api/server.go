@@ -9,8 +9,13 @@ import (
 	"os"
 
 	"github.com/go-fuego/fuego"
+	"github.com/go-fuego/fuego/option"
+	"github.com/yyewolf/rodent/cache"
+	"github.com/yyewolf/rodent/jobs"
+	"github.com/yyewolf/rodent/metrics"
 	"github.com/yyewolf/rodent/mischief"
 	"github.com/yyewolf/rodent/reaper"
+	"github.com/yyewolf/rodent/storage"
 )
 
 // ApiServer is the main struct of the API server.
@@ -21,11 +26,21 @@ type ApiServer struct {
 	port string
 	// host is the host to run the API server on
 	host string
+	// maxBodySize is the maximum size of a request body
+	maxBodySize int64
 
 	// reaper is the reaper to use
 	reaper *reaper.Reaper
 	// mischief is the Mischief instance to use
 	mischief *mischief.Mischief
+	// jobQueue is the queue of the asynchronous screenshots
+	jobQueue *jobs.Queue
+	// coalescer shares the renders of identical concurrent screenshots, nil when disabled
+	coalescer *cache.Coalescer
+	// cache holds the screenshots taken by the screenshot routes, nil when disabled
+	cache *cache.Cache
+	// storage is where screenshots are written with store=true, nil when not configured
+	storage storage.Storage
 	// logger is the logger of the API server
 	logger *slog.Logger
 
@@ -43,6 +58,7 @@ type ApiServerOpt func(*ApiServer)
 //	a := api.New(
 //		api.WithHost("0.0.0.0"),
 //		api.WithPort("8080"),
+//		api.WithMaxBodySize(5 << 20),
 //	)
 //
 // By default, a new Mischief instance is created with default values.
@@ -52,6 +68,7 @@ func New(opts ...ApiServerOpt) (*ApiServer, error) {
 	var defaultOpts = []ApiServerOpt{
 		WithHost("0.0.0.0"),
 		WithPort("8080"),
+		WithMaxBodySize(5 << 20),
 		WithLogger(slog.Default()),
 	}
 
@@ -70,6 +87,13 @@ func New(opts ...ApiServerOpt) (*ApiServer, error) {
 		apiServer.mischief = mischief
 	}
 
+	if apiServer.jobQueue == nil {
+		apiServer.jobQueue = jobs.New(apiServer.mischief,
+			jobs.WithStorage(apiServer.storage),
+			jobs.WithLogger(apiServer.logger),
+		)
+	}
+
 	if apiServer.reaper == nil {
 		apiServer.reaper = reaper.NewReaper(apiServer.logger)
 	}
@@ -77,6 +101,7 @@ func New(opts ...ApiServerOpt) (*ApiServer, error) {
 	apiServer.server = fuego.NewServer(
 		fuego.WithAddr(fmt.Sprintf("%s:%s", apiServer.host, apiServer.port)),
 		fuego.WithLogHandler(apiServer.logger.Handler()),
+		fuego.WithMaxBodySize(apiServer.maxBodySize),
 		fuego.WithEngineOptions(
 			fuego.WithOpenAPIConfig(fuego.OpenAPIConfig{
 				DisableLocalSave: true,
@@ -92,10 +117,17 @@ func New(opts ...ApiServerOpt) (*ApiServer, error) {
 // register registers the API server routes.
 func (apiServer *ApiServer) register() {
 	var repositories = []Repository{
-		NewScreenshotRepository(apiServer.mischief, apiServer.logger),
+		NewScreenshotRepository(apiServer.mischief, apiServer.coalescer, apiServer.cache, apiServer.storage, apiServer.logger, apiServer.maxBodySize),
+		NewPDFRepository(apiServer.mischief, apiServer.logger),
 		NewCleanupRepository(apiServer.mischief, apiServer.logger),
+		NewJobsRepository(apiServer.mischief, apiServer.jobQueue, apiServer.logger),
 	}
 
+	// Middlewares only apply to the routes registered after them
+	fuego.Use(apiServer.server, metricsMiddleware)
+
+	fuego.GetStd(apiServer.server, "/metrics", metrics.Handler().ServeHTTP, option.Hide())
+
 	group := fuego.Group(apiServer.server, "/api")
 
 	for _, repository := range repositories {
@@ -110,6 +142,10 @@ func (apiServer *ApiServer) Start() {
 
 		err := apiServer.server.Run()
 
+		apiServer.jobQueue.Stop()
+		if apiServer.cache != nil {
+			apiServer.cache.Close()
+		}
 		_ = apiServer.mischief.Destroy(context.Background())
 		apiServer.reaper.Shutdown()
 
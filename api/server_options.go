@@ -3,7 +3,10 @@ package api
 import (
 	"log/slog"
 
+	"github.com/yyewolf/rodent/cache"
+	"github.com/yyewolf/rodent/jobs"
 	"github.com/yyewolf/rodent/mischief"
+	"github.com/yyewolf/rodent/storage"
 )
 
 // WithHost sets the host to run the API server on.
@@ -32,6 +35,22 @@ func WithPort(port string) ApiServerOpt {
 	}
 }
 
+// WithMaxBodySize sets the maximum size in bytes of a request body,
+// such as the HTML document to render.
+//
+// By default, this is set to 5 MiB.
+//
+// Example:
+//
+//	a := api.New(
+//		api.WithMaxBodySize(10 << 20),
+//	)
+func WithMaxBodySize(size int64) ApiServerOpt {
+	return func(a *ApiServer) {
+		a.maxBodySize = size
+	}
+}
+
 // WithMischief sets the Mischief instance to use.
 //
 // Example:
@@ -45,6 +64,73 @@ func WithMischief(mischief *mischief.Mischief) ApiServerOpt {
 	}
 }
 
+// WithJobQueue sets the job queue used by the jobs API.
+//
+// By default, a queue with default values is created in front of the Mischief instance.
+//
+// Example:
+//
+//	a := api.New(
+//		api.WithJobQueue(jobs.New(m)),
+//	)
+func WithJobQueue(queue *jobs.Queue) ApiServerOpt {
+	return func(a *ApiServer) {
+		a.jobQueue = queue
+	}
+}
+
+// WithCoalescer sets the coalescer sharing a single render between identical
+// screenshots requested concurrently to the screenshot routes.
+//
+// It must be in front of the Mischief instance of the API server, and given
+// to the cache with cache.WithCoalescer when there is one.
+// By default, every screenshot is rendered separately.
+//
+// Example:
+//
+//	a := api.New(
+//		api.WithMischief(m),
+//		api.WithCoalescer(cache.NewCoalescer(m, slog.Default())),
+//	)
+func WithCoalescer(coalescer *cache.Coalescer) ApiServerOpt {
+	return func(a *ApiServer) {
+		a.coalescer = coalescer
+	}
+}
+
+// WithCache sets the cache of the screenshots taken by the screenshot routes.
+//
+// It must be in front of the Mischief instance of the API server.
+// By default, screenshots are not cached.
+//
+// Example:
+//
+//	a := api.New(
+//		api.WithMischief(m),
+//		api.WithCache(c),
+//	)
+func WithCache(cache *cache.Cache) ApiServerOpt {
+	return func(a *ApiServer) {
+		a.cache = cache
+	}
+}
+
+// WithStorage sets the storage screenshots are written to with store=true.
+//
+// It is also given to the default job queue, a queue set with WithJobQueue
+// needs its own jobs.WithStorage option. By default, no storage is set.
+//
+// Example:
+//
+//	a := api.New(
+//		api.WithStorage(s),
+//	)
+func WithStorage(storage storage.Storage) ApiServerOpt {
+	return func(a *ApiServer) {
+		a.storage = storage
+	}
+}
+
 // WithLogger is an option to set the logger of the API server.
 //
 // By default, the logger is set to slog.Default().
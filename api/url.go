@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// validateTargetURL parses and validates the URL of the page to render.
+func validateTargetURL(unsafeUrl string) (*url.URL, error) {
+	parsedUrl, err := url.Parse(unsafeUrl)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+
+	if parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https" {
+		return nil, ErrInvalidURLScheme
+	}
+
+	if parsedUrl.Port() != "" {
+		return nil, ErrURLWithPort
+	}
+
+	return parsedUrl, nil
+}
+
+// validateTargets validates, in place, the URLs of the page to render and checks
+// that their domain is allowed before waiting for a browser.
+//
+// It returns the status code to answer with when they are not valid.
+func validateTargets(m *mischief.Mischief, options *mischief.ScreenshotOptions) (int, error) {
+	for _, unsafeUrl := range []*string{&options.URL, &options.BaseURL} {
+		if *unsafeUrl == "" {
+			continue
+		}
+
+		parsedUrl, err := validateTargetURL(*unsafeUrl)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		err = m.CheckDomain(parsedUrl.Hostname())
+		if err != nil {
+			return http.StatusForbidden, err
+		}
+
+		*unsafeUrl = parsedUrl.String()
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,259 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yyewolf/rodent/metrics"
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// Cache takes screenshots through a Mischief instance and keeps them,
+// by their canonical options, in an in-memory tier and an optional
+// on-disk tier.
+//
+// Entries found in the disk tier are promoted to the memory tier.
+type Cache struct {
+	// mischief is the Mischief instance taking the screenshots
+	mischief *mischief.Mischief
+	// coalescer shares the renders of concurrent misses, nil when disabled
+	coalescer *Coalescer
+	// logger is the logger of the cache
+	logger *slog.Logger
+
+	// ttl is the duration entries are fresh for
+	ttl time.Duration
+	// memorySize is the maximum size of the images held in memory
+	memorySize int64
+	// directory is the directory of the disk tier, disabled when empty
+	directory string
+
+	memory *memory
+	disk   *disk
+
+	// ctx is cancelled when the cache is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type CacheOpt func(*Cache)
+
+// Control is the per request control of the cache.
+type Control struct {
+	// MaxAge is the maximum age of a cached screenshot, the cache TTL when 0 or greater
+	MaxAge time.Duration
+	// NoCache takes a new screenshot, which still replaces the cached one
+	NoCache bool
+}
+
+// New creates a new Cache instance in front of the Mischief instance.
+//
+// Example (and default values):
+//
+//	c, err := cache.New(m,
+//		cache.WithTTL(5*time.Minute),
+//		cache.WithMemorySize(64 << 20),
+//		cache.WithDirectory(""),
+//		cache.WithLogger(slog.Default()),
+//	)
+func New(mischief *mischief.Mischief, opts ...CacheOpt) (*Cache, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &Cache{
+		mischief: mischief,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	var defaultOpts = []CacheOpt{
+		WithTTL(5 * time.Minute),
+		WithMemorySize(64 << 20),
+		WithLogger(slog.Default()),
+	}
+
+	opts = append(defaultOpts, opts...)
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.memory = newMemory(c.memorySize)
+
+	if c.directory != "" {
+		var err error
+		c.disk, err = newDisk(c.directory)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		c.wg.Add(1)
+		go c.expire()
+	}
+
+	return c, nil
+}
+
+// TakeScreenshot returns the cached screenshot of the options when it is fresh
+// enough, or takes it and caches it. It reports whether it was cached.
+//
+// Screenshots that are not Cacheable are always taken.
+func (c *Cache) TakeScreenshot(ctx context.Context, options mischief.ScreenshotOptions, control Control) (Entry, bool, error) {
+	if !Cacheable(options) {
+		screenshot, err := c.mischief.Capture(ctx, options)
+		if err != nil {
+			return Entry{}, false, err
+		}
+
+		return newScreenshotEntry(screenshot, options.Format.ContentType()), false, nil
+	}
+
+	entry, ok := c.Lookup(options, control)
+	if ok {
+		return entry, true, nil
+	}
+
+	key, err := Key(options)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	metrics.CacheRequests.WithLabelValues("miss").Inc()
+
+	screenshot, shared, err := c.take(ctx, options)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	entry = newScreenshotEntry(screenshot, options.Format.ContentType())
+
+	// The request that started a shared render caches it for every waiter
+	if !shared {
+		c.set(key, entry)
+	}
+
+	return entry, false, nil
+}
+
+// Lookup returns the cached screenshot of the options when it is fresh enough,
+// without taking it. It never finds the screenshots that are not Cacheable,
+// nor the ones requested without cache.
+func (c *Cache) Lookup(options mischief.ScreenshotOptions, control Control) (Entry, bool) {
+	if control.NoCache || !Cacheable(options) {
+		return Entry{}, false
+	}
+
+	key, err := Key(options)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	entry, ok := c.get(key, control.MaxAge)
+	if ok {
+		metrics.CacheRequests.WithLabelValues("hit").Inc()
+	}
+
+	return entry, ok
+}
+
+// take takes the screenshot, through the coalescer when it is set.
+// It reports whether the render was shared with an earlier request.
+func (c *Cache) take(ctx context.Context, options mischief.ScreenshotOptions) (mischief.Screenshot, bool, error) {
+	if c.coalescer != nil {
+		return c.coalescer.Capture(ctx, options)
+	}
+
+	screenshot, err := c.mischief.Capture(ctx, options)
+
+	return screenshot, false, err
+}
+
+// get returns the entry of the key if it is younger than the max age.
+func (c *Cache) get(key string, maxAge time.Duration) (Entry, bool) {
+	if maxAge <= 0 || maxAge > c.ttl {
+		maxAge = c.ttl
+	}
+
+	entry, ok := c.memory.get(key)
+	if ok {
+		if entry.Age() <= maxAge {
+			return entry, true
+		}
+
+		// Stale entries are kept until the TTL, a later request may accept them
+		if entry.Age() > c.ttl {
+			c.memory.delete(key)
+		}
+
+		return Entry{}, false
+	}
+
+	if c.disk == nil {
+		return Entry{}, false
+	}
+
+	entry, err := c.disk.get(key)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			c.logger.Warn("error while reading cache entry", slog.Any("key", key), slog.Any("error", err))
+		}
+
+		return Entry{}, false
+	}
+
+	if entry.Age() > c.ttl {
+		c.disk.delete(key)
+		return Entry{}, false
+	}
+
+	c.memory.set(key, entry)
+
+	return entry, entry.Age() <= maxAge
+}
+
+// set adds the entry of the key to every tier.
+func (c *Cache) set(key string, entry Entry) {
+	c.memory.set(key, entry)
+
+	if c.disk == nil {
+		return
+	}
+
+	err := c.disk.set(key, entry)
+	if err != nil {
+		c.logger.Warn("error while writing cache entry", slog.Any("key", key), slog.Any("error", err))
+	}
+}
+
+// expire removes the expired entries of the disk tier until the cache is closed.
+func (c *Cache) expire() {
+	defer c.wg.Done()
+
+	interval := min(c.ttl, 10*time.Minute)
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case now := <-time.After(interval):
+			err := c.disk.expire(now.Add(-c.ttl))
+			if err != nil {
+				c.logger.Warn("error while expiring cache entries", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Close stops expiring the entries of the disk tier, which are kept.
+func (c *Cache) Close() {
+	c.cancel()
+	c.wg.Wait()
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"log/slog"
+	"time"
+)
+
+// WithTTL is an option to set the duration screenshots are cached for.
+//
+// Requests can accept younger screenshots only, not older ones.
+//
+// By default, this is set to 5 minutes.
+//
+// Example:
+//
+//	c, err := cache.New(m,
+//		cache.WithTTL(time.Hour),
+//	)
+func WithTTL(ttl time.Duration) CacheOpt {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithMemorySize is an option to set the maximum size, in bytes, of the
+// screenshots held in memory, the least recently used being evicted.
+//
+// By default, this is set to 64 MiB.
+//
+// Example:
+//
+//	c, err := cache.New(m,
+//		cache.WithMemorySize(256 << 20),
+//	)
+func WithMemorySize(size int64) CacheOpt {
+	return func(c *Cache) {
+		c.memorySize = size
+	}
+}
+
+// WithDirectory is an option to set the directory of the on-disk tier,
+// keeping the screenshots evicted from memory until their TTL.
+//
+// By default, the on-disk tier is disabled.
+//
+// Example:
+//
+//	c, err := cache.New(m,
+//		cache.WithDirectory("/var/cache/rodent"),
+//	)
+func WithDirectory(directory string) CacheOpt {
+	return func(c *Cache) {
+		c.directory = directory
+	}
+}
+
+// WithCoalescer is an option to share the renders of identical
+// screenshots missing from the cache concurrently.
+//
+// The coalescer must be in front of the same Mischief instance.
+// By default, concurrent misses are rendered separately.
+//
+// Example:
+//
+//	c, err := cache.New(m,
+//		cache.WithCoalescer(cache.NewCoalescer(m, slog.Default())),
+//	)
+func WithCoalescer(coalescer *Coalescer) CacheOpt {
+	return func(c *Cache) {
+		c.coalescer = coalescer
+	}
+}
+
+// WithLogger is an option to set the logger of the cache.
+//
+// By default, the logger is set to slog.Default().
+//
+// Example:
+//
+//	c, err := cache.New(m,
+//		cache.WithLogger(slog.Default()),
+//	)
+func WithLogger(logger *slog.Logger) CacheOpt {
+	return func(c *Cache) {
+		c.logger = logger
+	}
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// newTestCache creates a cache without Mischief instance, only its tiers are used.
+func newTestCache(t *testing.T, opts ...CacheOpt) *Cache {
+	t.Helper()
+
+	defaultOpts := []CacheOpt{
+		WithTTL(time.Minute),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}
+
+	c, err := New(nil, append(defaultOpts, opts...)...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+// agedEntry returns an entry taken the given duration ago.
+func agedEntry(age time.Duration) Entry {
+	entry := sizedEntry(10)
+	entry.CreatedAt = time.Now().Add(-age)
+
+	return entry
+}
+
+func TestCacheExpiry(t *testing.T) {
+	tests := []struct {
+		name   string
+		age    time.Duration
+		maxAge time.Duration
+		want   bool
+		// kept reports whether the entry is still held after the lookup
+		kept bool
+	}{
+		{"fresh", 10 * time.Second, 0, true, true},
+		{"older than the TTL", 2 * time.Minute, 0, false, false},
+		{"younger than the max age", 10 * time.Second, 30 * time.Second, true, true},
+		{"older than the max age", 40 * time.Second, 30 * time.Second, false, true},
+		{"max age above the TTL", 2 * time.Minute, time.Hour, false, false},
+	}
+
+	for _, tier := range []string{"memory", "disk"} {
+		for _, tt := range tests {
+			t.Run(tier+"/"+tt.name, func(t *testing.T) {
+				c := newTestCache(t, WithDirectory(t.TempDir()))
+
+				entry := agedEntry(tt.age)
+				c.set("key", entry)
+
+				if tier == "disk" {
+					c.memory.delete("key")
+				}
+
+				got, ok := c.get("key", tt.maxAge)
+				if ok != tt.want {
+					t.Fatalf("get() hit = %v, want %v", ok, tt.want)
+				}
+
+				if ok && got.ETag != entry.ETag {
+					t.Errorf("get() ETag = %s, want %s", got.ETag, entry.ETag)
+				}
+
+				// Stale entries are kept until the TTL, a later request may accept them
+				_, kept := c.memory.get("key")
+				if tier == "disk" {
+					_, err := c.disk.get("key")
+					kept = err == nil
+				}
+
+				if kept != tt.kept {
+					t.Errorf("entry kept = %v, want %v", kept, tt.kept)
+				}
+			})
+		}
+	}
+}
+
+func TestDiskExpire(t *testing.T) {
+	c := newTestCache(t, WithDirectory(t.TempDir()))
+
+	for _, key := range []string{"old", "new"} {
+		err := c.disk.set(key, sizedEntry(10))
+		if err != nil {
+			t.Fatalf("set() error = %v", err)
+		}
+	}
+
+	past := time.Now().Add(-time.Hour)
+	err := os.Chtimes(c.disk.path("old"), past, past)
+	if err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	err = c.disk.expire(time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("expire() error = %v", err)
+	}
+
+	if _, err := c.disk.get("old"); !os.IsNotExist(err) {
+		t.Errorf("get(\"old\") error = %v, want not exist", err)
+	}
+
+	if _, err := c.disk.get("new"); err != nil {
+		t.Errorf("get(\"new\") error = %v", err)
+	}
+}
+
+func TestCacheLookup(t *testing.T) {
+	options := mischief.ScreenshotOptions{URL: "https://example.com/"}
+
+	tests := []struct {
+		name    string
+		options mischief.ScreenshotOptions
+		control Control
+		want    bool
+	}{
+		{"cached", options, Control{}, true},
+		{"within the max age", options, Control{MaxAge: 30 * time.Second}, true},
+		{"older than the max age", options, Control{MaxAge: time.Second}, false},
+		{"no cache", options, Control{NoCache: true}, false},
+		{"other options", mischief.ScreenshotOptions{URL: "https://example.com/other"}, Control{}, false},
+		{"private", mischief.ScreenshotOptions{URL: "https://example.com/", Headers: map[string]string{"X-Tenant": "acme"}}, Control{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache(t)
+
+			key, err := Key(options)
+			if err != nil {
+				t.Fatalf("Key() error = %v", err)
+			}
+
+			entry := agedEntry(10 * time.Second)
+			c.set(key, entry)
+
+			got, ok := c.Lookup(tt.options, tt.control)
+			if ok != tt.want {
+				t.Fatalf("Lookup() hit = %v, want %v", ok, tt.want)
+			}
+
+			if ok && got.ETag != entry.ETag {
+				t.Errorf("Lookup() ETag = %s, want %s", got.ETag, entry.ETag)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/yyewolf/rodent/metrics"
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// Coalescer shares a single render between identical screenshots
+// requested concurrently, by their canonical options, so that they
+// occupy a single browser page.
+//
+// The render outlives the request that started it, it is only cancelled
+// once every request waiting for it is gone.
+type Coalescer struct {
+	// mischief takes the screenshots
+	mischief screenshotter
+	// logger is the logger of the coalescer
+	logger *slog.Logger
+
+	// mu guards the renders in flight
+	mu      sync.Mutex
+	renders map[string]*render
+}
+
+// screenshotter takes the screenshots, a Mischief instance outside of the tests.
+type screenshotter interface {
+	Capture(ctx context.Context, options mischief.ScreenshotOptions) (mischief.Screenshot, error)
+}
+
+// render is a screenshot in flight, shared by its waiters.
+type render struct {
+	// done is closed once screenshot and err are set
+	done       chan struct{}
+	screenshot mischief.Screenshot
+	err        error
+
+	// waiters is the number of requests waiting for the render
+	waiters int
+	// cancel cancels the render once it has no waiter left
+	cancel context.CancelFunc
+}
+
+// NewCoalescer creates a new Coalescer instance in front of the Mischief instance.
+func NewCoalescer(mischief *mischief.Mischief, logger *slog.Logger) *Coalescer {
+	return &Coalescer{
+		mischief: mischief,
+		logger:   logger,
+		renders:  map[string]*render{},
+	}
+}
+
+// Capture takes the screenshot, or joins the identical one in flight.
+// It reports whether the render was shared with an earlier request.
+//
+// Screenshots that are not Cacheable show private content, they are never shared.
+func (c *Coalescer) Capture(ctx context.Context, options mischief.ScreenshotOptions) (mischief.Screenshot, bool, error) {
+	if !Cacheable(options) {
+		screenshot, err := c.mischief.Capture(ctx, options)
+		return screenshot, false, err
+	}
+
+	key, err := Key(options)
+	if err != nil {
+		return mischief.Screenshot{}, false, err
+	}
+
+	c.mu.Lock()
+	r, shared := c.renders[key]
+	if !shared {
+		// The values of the context are kept, not its cancellation
+		renderCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+		r = &render{done: make(chan struct{}), cancel: cancel}
+		c.renders[key] = r
+
+		go c.render(renderCtx, key, r, options)
+	}
+	r.waiters++
+	c.mu.Unlock()
+
+	if shared {
+		metrics.CoalescedRequests.Inc()
+		c.logger.Info("screenshot coalesced", slog.Any("key", key))
+	}
+
+	select {
+	case <-r.done:
+		return r.screenshot, shared, r.err
+	case <-ctx.Done():
+		c.leave(key, r)
+		return mischief.Screenshot{}, shared, ctx.Err()
+	}
+}
+
+// render takes the screenshot and hands it to its waiters.
+func (c *Coalescer) render(ctx context.Context, key string, r *render, options mischief.ScreenshotOptions) {
+	defer r.cancel()
+
+	r.screenshot, r.err = c.mischief.Capture(ctx, options)
+
+	c.mu.Lock()
+	if c.renders[key] == r {
+		delete(c.renders, key)
+	}
+	c.mu.Unlock()
+
+	close(r.done)
+}
+
+// leave removes a waiter gone before the render finished,
+// the render is cancelled when it was the last one.
+func (c *Coalescer) leave(key string, r *render) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r.waiters--
+	if r.waiters > 0 {
+		return
+	}
+
+	// Later requests start a new render rather than joining the cancelled one
+	if c.renders[key] == r {
+		delete(c.renders, key)
+	}
+
+	r.cancel()
+}
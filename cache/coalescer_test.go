@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// stubRenderer renders until released, or until its context is cancelled.
+type stubRenderer struct {
+	release chan struct{}
+	// started receives the context of every render once it started
+	started chan context.Context
+	calls   atomic.Int32
+}
+
+func newStubRenderer() *stubRenderer {
+	return &stubRenderer{
+		release: make(chan struct{}),
+		started: make(chan context.Context, 100),
+	}
+}
+
+func (s *stubRenderer) Capture(ctx context.Context, options mischief.ScreenshotOptions) (mischief.Screenshot, error) {
+	s.calls.Add(1)
+	s.started <- ctx
+
+	select {
+	case <-s.release:
+		return mischief.Screenshot{Data: []byte(options.URL)}, nil
+	case <-ctx.Done():
+		return mischief.Screenshot{}, ctx.Err()
+	}
+}
+
+func newTestCoalescer(renderer screenshotter) *Coalescer {
+	return &Coalescer{
+		mischief: renderer,
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		renders:  map[string]*render{},
+	}
+}
+
+// waitForWaiters waits until the render of the options has the given number of waiters.
+func waitForWaiters(t *testing.T, c *Coalescer, options mischief.ScreenshotOptions, waiters int) {
+	t.Helper()
+
+	key, err := Key(options)
+	if err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		r, ok := c.renders[key]
+		got := 0
+		if ok {
+			got = r.waiters
+		}
+		c.mu.Unlock()
+
+		if got == waiters {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("render never had %d waiters", waiters)
+}
+
+// result is the outcome of a call to Capture.
+type result struct {
+	data   []byte
+	shared bool
+	err    error
+}
+
+// takeConcurrently calls Capture once per context, concurrently.
+func takeConcurrently(c *Coalescer, contexts []context.Context, options mischief.ScreenshotOptions) (*sync.WaitGroup, []result) {
+	var wg sync.WaitGroup
+	results := make([]result, len(contexts))
+
+	for i, ctx := range contexts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			screenshot, shared, err := c.Capture(ctx, options)
+			results[i] = result{screenshot.Data, shared, err}
+		}()
+	}
+
+	return &wg, results
+}
+
+func TestCoalescerSharesRender(t *testing.T) {
+	renderer := newStubRenderer()
+	c := newTestCoalescer(renderer)
+	options := mischief.ScreenshotOptions{URL: "https://example.com/"}
+
+	const callers = 10
+
+	contexts := make([]context.Context, callers)
+	for i := range contexts {
+		contexts[i] = context.Background()
+	}
+
+	wg, results := takeConcurrently(c, contexts, options)
+
+	waitForWaiters(t, c, options, callers)
+	close(renderer.release)
+	wg.Wait()
+
+	if calls := renderer.calls.Load(); calls != 1 {
+		t.Fatalf("renderer called %d times, want 1", calls)
+	}
+
+	starters := 0
+	for i, r := range results {
+		if r.err != nil {
+			t.Errorf("caller %d error = %v", i, r.err)
+		}
+
+		if string(r.data) != options.URL {
+			t.Errorf("caller %d data = %q, want %q", i, r.data, options.URL)
+		}
+
+		if !r.shared {
+			starters++
+		}
+	}
+
+	if starters != 1 {
+		t.Errorf("%d callers started the render, want 1", starters)
+	}
+
+	if len(c.renders) != 0 {
+		t.Errorf("%d renders left in flight, want 0", len(c.renders))
+	}
+}
+
+func TestCoalescerCancelsRenderWithoutWaiters(t *testing.T) {
+	renderer := newStubRenderer()
+	c := newTestCoalescer(renderer)
+	options := mischief.ScreenshotOptions{URL: "https://example.com/"}
+
+	const callers = 3
+
+	contexts := make([]context.Context, callers)
+	cancels := make([]context.CancelFunc, callers)
+	for i := range contexts {
+		contexts[i], cancels[i] = context.WithCancel(context.Background())
+	}
+
+	wg, results := takeConcurrently(c, contexts, options)
+
+	waitForWaiters(t, c, options, callers)
+	renderCtx := <-renderer.started
+
+	// The render goes on while a waiter is left
+	for _, cancel := range cancels[:callers-1] {
+		cancel()
+	}
+
+	waitForWaiters(t, c, options, 1)
+
+	if renderCtx.Err() != nil {
+		t.Fatalf("render cancelled with a waiter left, error = %v", renderCtx.Err())
+	}
+
+	cancels[callers-1]()
+	wg.Wait()
+
+	select {
+	case <-renderCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("render not cancelled once every waiter left")
+	}
+
+	for i, r := range results {
+		if !errors.Is(r.err, context.Canceled) {
+			t.Errorf("caller %d error = %v, want %v", i, r.err, context.Canceled)
+		}
+	}
+
+	// A new caller starts a fresh render instead of joining the cancelled one
+	wg, results = takeConcurrently(c, []context.Context{context.Background()}, options)
+
+	freshCtx := <-renderer.started
+	if freshCtx.Err() != nil {
+		t.Fatalf("fresh render started cancelled, error = %v", freshCtx.Err())
+	}
+
+	close(renderer.release)
+	wg.Wait()
+
+	if calls := renderer.calls.Load(); calls != 2 {
+		t.Errorf("renderer called %d times, want 2", calls)
+	}
+
+	if results[0].err != nil || results[0].shared {
+		t.Errorf("fresh caller shared = %v, error = %v, want its own render", results[0].shared, results[0].err)
+	}
+}
+
+func TestCoalescerDoesNotSharePrivateRenders(t *testing.T) {
+	renderer := newStubRenderer()
+	c := newTestCoalescer(renderer)
+	options := mischief.ScreenshotOptions{
+		URL:     "https://example.com/",
+		Cookies: []mischief.Cookie{{Name: "session", Value: "secret"}},
+	}
+
+	wg, results := takeConcurrently(c, []context.Context{context.Background(), context.Background()}, options)
+
+	<-renderer.started
+	<-renderer.started
+	close(renderer.release)
+	wg.Wait()
+
+	for i, r := range results {
+		if r.err != nil || r.shared {
+			t.Errorf("caller %d shared = %v, error = %v, want its own render", i, r.shared, r.err)
+		}
+	}
+}
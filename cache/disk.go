@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// disk is the on-disk tier of the cache, holding an entry per file:
+// a line of JSON metadata followed by the image.
+type disk struct {
+	// directory is the directory holding the entries
+	directory string
+}
+
+func newDisk(directory string) (*disk, error) {
+	err := os.MkdirAll(directory, 0o755)
+	if err != nil {
+		return nil, errors.Join(ErrCreatingCache, err)
+	}
+
+	return &disk{directory: directory}, nil
+}
+
+// path returns the file of the key, spread across subdirectories.
+func (d *disk) path(key string) string {
+	return filepath.Join(d.directory, key[:2], key)
+}
+
+// get reads the entry of the key.
+func (d *disk) get(key string) (Entry, error) {
+	file, err := os.Open(d.path(key))
+	if err != nil {
+		return Entry{}, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	metadata, err := reader.ReadBytes('\n')
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var entry Entry
+	err = json.Unmarshal(metadata, &entry)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry.Data, err = io.ReadAll(reader)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return entry, nil
+}
+
+// set writes the entry of the key, replacing it atomically.
+func (d *disk) set(key string, entry Entry) error {
+	metadata, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	name := d.path(key)
+
+	err = os.MkdirAll(filepath.Dir(name), 0o755)
+	if err != nil {
+		return err
+	}
+
+	// The entry is written aside then renamed, readers never see a partial file
+	file, err := os.CreateTemp(filepath.Dir(name), ".entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, io.MultiReader(bytes.NewReader(metadata), bytes.NewReader([]byte("\n")), bytes.NewReader(entry.Data)))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), name)
+}
+
+// delete removes the entry of the key, if any.
+func (d *disk) delete(key string) {
+	_ = os.Remove(d.path(key))
+}
+
+// expire removes the entries written before the deadline, and the
+// temporary files left behind by an interrupted write.
+func (d *disk) expire(deadline time.Time) error {
+	return filepath.WalkDir(d.directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+
+		if info.ModTime().Before(deadline) {
+			_ = os.Remove(path)
+		}
+
+		return nil
+	})
+}
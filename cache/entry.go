@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// Entry is a screenshot in the cache.
+type Entry struct {
+	// Data is the image
+	Data []byte `json:"-"`
+	// ContentType is the content type of the image
+	ContentType string `json:"contentType"`
+	// ETag is the strong entity tag of the image
+	ETag string `json:"etag"`
+	// CreatedAt is the date the screenshot was taken
+	CreatedAt time.Time `json:"createdAt"`
+	// Steps are the results of the steps of the render, not kept on disk
+	Steps []mischief.StepResult `json:"-"`
+}
+
+// NewEntry creates the entry of an image taken now.
+func NewEntry(data []byte, contentType string) Entry {
+	return Entry{
+		Data:        data,
+		ContentType: contentType,
+		ETag:        ETag(data),
+		CreatedAt:   time.Now(),
+	}
+}
+
+// newScreenshotEntry creates the entry of a screenshot taken now.
+func newScreenshotEntry(screenshot mischief.Screenshot, contentType string) Entry {
+	entry := NewEntry(screenshot.Data, contentType)
+	entry.Steps = screenshot.Steps
+
+	return entry
+}
+
+// Age returns how long ago the screenshot was taken.
+func (e Entry) Age() time.Duration {
+	return time.Since(e.CreatedAt)
+}
+
+// ETag returns the strong entity tag of the image, quoted as in the ETag header.
+func ETag(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
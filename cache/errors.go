@@ -0,0 +1,8 @@
+package cache
+
+import "errors"
+
+var (
+	ErrCreatingCache = errors.New("error when creating cache")
+	ErrComputingKey  = errors.New("error when computing cache key")
+)
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// Cacheable reports whether the screenshot can be cached.
+//
+// Screenshots sent with headers, cookies or credentials show private
+// content, they are never cached.
+func Cacheable(options mischief.ScreenshotOptions) bool {
+	return len(options.Headers) == 0 && len(options.Cookies) == 0 && options.BasicAuth == nil
+}
+
+// Key returns the key of the screenshot in the cache, the SHA-256 of its
+// canonical options, so that equivalent options share the same key.
+func Key(options mischief.ScreenshotOptions) (string, error) {
+	options.URL = canonicalURL(options.URL)
+	options.BaseURL = canonicalURL(options.BaseURL)
+
+	if options.Format == "" {
+		options.Format = mischief.FormatPNG
+	}
+
+	// Maps are marshalled with sorted keys, the encoding is deterministic
+	b, err := json.Marshal(options)
+	if err != nil {
+		return "", errors.Join(ErrComputingKey, err)
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalURL normalizes the URL: lower case scheme and host, no default
+// port, a root path and sorted query parameters.
+//
+// Unparsable URLs are returned as is.
+func canonicalURL(rawUrl string) string {
+	if rawUrl == "" {
+		return rawUrl
+	}
+
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		u.Host = u.Hostname()
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	// The fragment is kept, it routes single page applications
+	u.RawQuery = u.Query().Encode()
+
+	return u.String()
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"empty", "", ""},
+		{"already canonical", "https://example.com/page?a=1", "https://example.com/page?a=1"},
+		{"upper case scheme and host", "HTTPS://Example.COM/Page", "https://example.com/Page"},
+		{"default https port", "https://example.com:443/", "https://example.com/"},
+		{"default http port", "http://example.com:80/", "http://example.com/"},
+		{"other port kept", "https://example.com:8443/", "https://example.com:8443/"},
+		{"http port on https kept", "https://example.com:80/", "https://example.com:80/"},
+		{"root path", "https://example.com", "https://example.com/"},
+		{"sorted query", "https://example.com/?b=2&a=1", "https://example.com/?a=1&b=2"},
+		{"repeated parameter order kept", "https://example.com/?a=2&a=1", "https://example.com/?a=2&a=1"},
+		{"query encoding normalized", "https://example.com/?q=a+b", "https://example.com/?q=a+b"},
+		{"fragment kept", "https://example.com/#/route", "https://example.com/#/route"},
+		{"unparsable kept", "https://exa mple.com/%zz", "https://exa mple.com/%zz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := canonicalURL(tt.url)
+			if got != tt.want {
+				t.Errorf("canonicalURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	base := mischief.ScreenshotOptions{URL: "https://example.com/?a=1&b=2"}
+
+	tests := []struct {
+		name    string
+		options mischief.ScreenshotOptions
+		same    bool
+	}{
+		{"identical", base, true},
+		{"equivalent URL", mischief.ScreenshotOptions{URL: "HTTPS://EXAMPLE.com:443/?b=2&a=1"}, true},
+		{"default format", mischief.ScreenshotOptions{URL: base.URL, Format: mischief.FormatPNG}, true},
+		{"other query", mischief.ScreenshotOptions{URL: "https://example.com/?a=1&b=3"}, false},
+		{"other path", mischief.ScreenshotOptions{URL: "https://example.com/other?a=1&b=2"}, false},
+		{"other fragment", mischief.ScreenshotOptions{URL: base.URL + "#/route"}, false},
+		{"other format", mischief.ScreenshotOptions{URL: base.URL, Format: mischief.FormatJPEG}, false},
+		{"full page", mischief.ScreenshotOptions{URL: base.URL, FullPage: true}, false},
+		{"viewport", mischief.ScreenshotOptions{URL: base.URL, Viewport: mischief.Viewport{Width: 800}}, false},
+		{"html", mischief.ScreenshotOptions{HTML: "<p>hello</p>"}, false},
+	}
+
+	want, err := Key(base)
+	if err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Key(tt.options)
+			if err != nil {
+				t.Fatalf("Key() error = %v", err)
+			}
+
+			if (got == want) != tt.same {
+				t.Errorf("Key() = %s, base key %s, want same = %v", got, want, tt.same)
+			}
+		})
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	url := "https://example.com/"
+
+	tests := []struct {
+		name    string
+		options mischief.ScreenshotOptions
+		want    bool
+	}{
+		{"public", mischief.ScreenshotOptions{URL: url}, true},
+		{"headers", mischief.ScreenshotOptions{URL: url, Headers: map[string]string{"Authorization": "Bearer token"}}, false},
+		{"cookies", mischief.ScreenshotOptions{URL: url, Cookies: []mischief.Cookie{{Name: "session", Value: "secret"}}}, false},
+		{"basic auth", mischief.ScreenshotOptions{URL: url, BasicAuth: &mischief.BasicAuth{Username: "user", Password: "secret"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Cacheable(tt.options)
+			if got != tt.want {
+				t.Errorf("Cacheable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// memory is the in-memory tier of the cache, evicting the least
+// recently used entries once its size is reached.
+type memory struct {
+	mu sync.Mutex
+	// maxBytes is the maximum size of the images held
+	maxBytes int64
+	// bytes is the size of the images held
+	bytes int64
+	// entries are ordered from the most to the least recently used
+	entries *list.List
+	// elements indexes the entries by key
+	elements map[string]*list.Element
+}
+
+// memoryItem is an entry of the memory tier, along with its key.
+type memoryItem struct {
+	key   string
+	entry Entry
+}
+
+func newMemory(maxBytes int64) *memory {
+	return &memory{
+		maxBytes: maxBytes,
+		entries:  list.New(),
+		elements: map[string]*list.Element{},
+	}
+}
+
+// get returns the entry of the key and marks it as recently used.
+func (m *memory) get(key string) (Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.elements[key]
+	if !ok {
+		return Entry{}, false
+	}
+
+	m.entries.MoveToFront(element)
+
+	return element.Value.(*memoryItem).entry, true
+}
+
+// set adds the entry, evicting the least recently used ones to make room.
+// Entries larger than the tier are not held.
+func (m *memory) set(key string, entry Entry) {
+	size := int64(len(entry.Data))
+	if size > m.maxBytes {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.elements[key]; ok {
+		m.remove(element)
+	}
+
+	m.elements[key] = m.entries.PushFront(&memoryItem{key: key, entry: entry})
+	m.bytes += size
+
+	for m.bytes > m.maxBytes {
+		m.remove(m.entries.Back())
+	}
+}
+
+// delete removes the entry of the key, if any.
+func (m *memory) delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.elements[key]; ok {
+		m.remove(element)
+	}
+}
+
+// remove removes the element, the lock must be held.
+func (m *memory) remove(element *list.Element) {
+	item := m.entries.Remove(element).(*memoryItem)
+	delete(m.elements, item.key)
+	m.bytes -= int64(len(item.entry.Data))
+}
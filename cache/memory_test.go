@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+)
+
+// sizedEntry returns an entry holding an image of the given size.
+func sizedEntry(size int) Entry {
+	return NewEntry(bytes.Repeat([]byte{'x'}, size), "image/png")
+}
+
+// memoryOp is an operation on the memory tier, a get when size is 0.
+type memoryOp struct {
+	key  string
+	size int
+}
+
+func setOp(key string, size int) memoryOp { return memoryOp{key, size} }
+func getOp(key string) memoryOp           { return memoryOp{key: key} }
+
+func TestMemoryEviction(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxBytes  int64
+		ops       []memoryOp
+		wantKeys  []string
+		wantGone  []string
+		wantBytes int64
+	}{
+		{
+			name:      "fits",
+			maxBytes:  30,
+			ops:       []memoryOp{setOp("a", 10), setOp("b", 10), setOp("c", 10)},
+			wantKeys:  []string{"a", "b", "c"},
+			wantBytes: 30,
+		},
+		{
+			name:      "least recently set evicted",
+			maxBytes:  30,
+			ops:       []memoryOp{setOp("a", 10), setOp("b", 10), setOp("c", 10), setOp("d", 10)},
+			wantKeys:  []string{"b", "c", "d"},
+			wantGone:  []string{"a"},
+			wantBytes: 30,
+		},
+		{
+			name:      "get marks as recently used",
+			maxBytes:  30,
+			ops:       []memoryOp{setOp("a", 10), setOp("b", 10), setOp("c", 10), getOp("a"), setOp("d", 10)},
+			wantKeys:  []string{"a", "c", "d"},
+			wantGone:  []string{"b"},
+			wantBytes: 30,
+		},
+		{
+			name:      "large entry evicts several",
+			maxBytes:  30,
+			ops:       []memoryOp{setOp("a", 10), setOp("b", 10), setOp("c", 10), setOp("d", 25)},
+			wantKeys:  []string{"d"},
+			wantGone:  []string{"a", "b", "c"},
+			wantBytes: 25,
+		},
+		{
+			name:      "entry larger than the tier not held",
+			maxBytes:  30,
+			ops:       []memoryOp{setOp("a", 10), setOp("b", 31)},
+			wantKeys:  []string{"a"},
+			wantGone:  []string{"b"},
+			wantBytes: 10,
+		},
+		{
+			name:      "replacing an entry updates its size",
+			maxBytes:  30,
+			ops:       []memoryOp{setOp("a", 10), setOp("b", 10), setOp("a", 20)},
+			wantKeys:  []string{"a", "b"},
+			wantBytes: 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMemory(tt.maxBytes)
+
+			for _, op := range tt.ops {
+				if op.size == 0 {
+					m.get(op.key)
+					continue
+				}
+
+				m.set(op.key, sizedEntry(op.size))
+			}
+
+			for _, key := range tt.wantKeys {
+				if _, ok := m.get(key); !ok {
+					t.Errorf("get(%q) missed, want a hit", key)
+				}
+			}
+
+			for _, key := range tt.wantGone {
+				if _, ok := m.get(key); ok {
+					t.Errorf("get(%q) hit, want a miss", key)
+				}
+			}
+
+			if m.bytes != tt.wantBytes {
+				t.Errorf("bytes = %d, want %d", m.bytes, tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestMemoryDelete(t *testing.T) {
+	m := newMemory(30)
+	m.set("a", sizedEntry(10))
+	m.delete("a")
+	m.delete("missing")
+
+	if _, ok := m.get("a"); ok {
+		t.Error("get(\"a\") hit after delete, want a miss")
+	}
+
+	if m.bytes != 0 {
+		t.Errorf("bytes = %d, want 0", m.bytes)
+	}
+}
@@ -9,7 +9,10 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/yyewolf/rodent/api"
+	"github.com/yyewolf/rodent/cache"
+	"github.com/yyewolf/rodent/jobs"
 	"github.com/yyewolf/rodent/mischief"
+	"github.com/yyewolf/rodent/webhook"
 )
 
 var (
@@ -22,6 +25,45 @@ var (
 	browserRetakeTimeout int
 	pageRetakeTimeout    int
 	pageStabilityTimeout int
+	fullPageMaxHeight    int
+	maxBodySize          int64
+	isolatedPages        bool
+
+	allowPrivateNetworks bool
+	allowedHosts         string
+	deniedHosts          string
+	allowedNetworks      string
+	deniedNetworks       string
+	allowedDomains       string
+	deniedDomains        string
+
+	blockAds           bool
+	blockResourceTypes string
+	blockURLPatterns   string
+
+	jobWorkers     int
+	jobQueueLength int
+	jobRetention   int
+
+	callbackSecret      string
+	callbackMaxAttempts int
+	callbackTimeout     int
+	callbackWorkers     int
+
+	storageDirectory string
+	storagePublicURL string
+	s3Endpoint       string
+	s3Bucket         string
+	s3Region         string
+	s3Prefix         string
+	s3Insecure       bool
+	s3PresignExpiry  int
+
+	coalesceRequests bool
+
+	cacheTTL        int
+	cacheMemorySize int64
+	cacheDirectory  string
 )
 
 // apiCmd represents the api command
@@ -32,24 +74,116 @@ var apiCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
-		mischief, err := mischief.New(
+		networkPolicy := mischief.NetworkPolicy{
+			AllowPrivateNetworks: allowPrivateNetworks,
+			AllowedHosts:         splitList(allowedHosts),
+			DeniedHosts:          splitList(deniedHosts),
+		}
+
+		var err error
+		networkPolicy.AllowedNetworks, err = mischief.ParseNetworks(splitList(allowedNetworks))
+		if err != nil {
+			panic(err)
+		}
+
+		networkPolicy.DeniedNetworks, err = mischief.ParseNetworks(splitList(deniedNetworks))
+		if err != nil {
+			panic(err)
+		}
+
+		mischiefOpts := []mischief.MischiefOpt{
 			mischief.WithBrowserConcurrency(browserConcurrency),
 			mischief.WithPageConcurrency(pageConcurrency),
-			mischief.WithBrowserRetakeTimeout(time.Duration(browserRetakeTimeout)*time.Second),
-			mischief.WithPageRetakeTimeout(time.Duration(pageRetakeTimeout)*time.Second),
-			mischief.WithPageStabilityTimeout(time.Duration(pageStabilityTimeout)*time.Second),
+			mischief.WithBrowserRetakeTimeout(time.Duration(browserRetakeTimeout) * time.Second),
+			mischief.WithPageRetakeTimeout(time.Duration(pageRetakeTimeout) * time.Second),
+			mischief.WithPageStabilityTimeout(time.Duration(pageStabilityTimeout) * time.Second),
+			mischief.WithFullPageMaxHeight(fullPageMaxHeight),
+			mischief.WithIsolatedPages(isolatedPages),
+			mischief.WithNetworkPolicy(networkPolicy),
+			mischief.WithAllowedDomains(splitList(allowedDomains)),
+			mischief.WithDeniedDomains(splitList(deniedDomains)),
+			mischief.WithResourceBlocking(resourceBlocking(blockAds, blockResourceTypes, blockURLPatterns)),
 			mischief.WithLogger(logger),
-		)
+		}
+
+		// External browsers replace the local ones, one rat per URL
+		if urls := splitList(browsers); len(urls) > 0 {
+			mischiefOpts = append(mischiefOpts, mischief.WithExternalBrowsers(urls))
+		}
+
+		mischief, err := mischief.New(mischiefOpts...)
 		if err != nil {
 			panic(err)
 		}
 
-		apiServer, err := api.New(
+		resultStorage, err := newStorage()
+		if err != nil {
+			panic(err)
+		}
+
+		jobOpts := []jobs.QueueOpt{
+			jobs.WithWorkers(jobWorkers),
+			jobs.WithQueueLength(jobQueueLength),
+			jobs.WithRetention(time.Duration(jobRetention) * time.Second),
+			jobs.WithCallbackWorkers(callbackWorkers),
+			jobs.WithStorage(resultStorage),
+			jobs.WithLogger(logger),
+		}
+
+		// Callbacks are signed, they are only enabled along with a secret
+		if callbackSecret != "" {
+			sender, err := webhook.New(
+				webhook.WithSecret([]byte(callbackSecret)),
+				webhook.WithMaxAttempts(callbackMaxAttempts),
+				webhook.WithTimeout(time.Duration(callbackTimeout)*time.Second),
+				webhook.WithNetworkPolicy(networkPolicy),
+				webhook.WithLogger(logger),
+			)
+			if err != nil {
+				panic(err)
+			}
+
+			jobOpts = append(jobOpts, jobs.WithWebhook(sender))
+		}
+
+		jobQueue := jobs.New(mischief, jobOpts...)
+
+		apiOpts := []api.ApiServerOpt{
 			api.WithHost(host),
 			api.WithPort(port),
+			api.WithMaxBodySize(maxBodySize),
 			api.WithMischief(mischief),
+			api.WithJobQueue(jobQueue),
+			api.WithStorage(resultStorage),
 			api.WithLogger(logger),
-		)
+		}
+
+		cacheOpts := []cache.CacheOpt{
+			cache.WithTTL(time.Duration(cacheTTL) * time.Second),
+			cache.WithMemorySize(cacheMemorySize),
+			cache.WithDirectory(cacheDirectory),
+			cache.WithLogger(logger),
+		}
+
+		// The same coalescer shares the renders of the cache misses
+		if coalesceRequests {
+			coalescer := cache.NewCoalescer(mischief, logger)
+
+			apiOpts = append(apiOpts, api.WithCoalescer(coalescer))
+			cacheOpts = append(cacheOpts, cache.WithCoalescer(coalescer))
+		}
+
+		// Screenshots are only cached when a TTL is set
+		if cacheTTL > 0 {
+			screenshotCache, err := cache.New(mischief, cacheOpts...)
+			if err != nil {
+				panic(err)
+			}
+
+			apiOpts = append(apiOpts, api.WithCache(screenshotCache))
+		}
+
+		apiServer, err := api.New(apiOpts...)
 		if err != nil {
 			panic(err)
 		}
@@ -82,10 +216,42 @@ func init() {
 	// apiCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	apiCmd.Flags().StringVarP(&port, "port", "p", "8080", "Port to run the API server on.")
 	apiCmd.Flags().StringVarP(&host, "host", "H", "localhost", "Host to run the API server on.")
-	apiCmd.Flags().StringVarP(&browsers, "browsers", "b", "http://localhost:9222", "URLs to connect to external browsers. Use commas to separate multiple URLs.")
+	apiCmd.Flags().StringVarP(&browsers, "browsers", "b", "", "URLs to connect to external browsers (e.g. http://localhost:9222) instead of launching local ones. Use commas to separate multiple URLs.")
 	apiCmd.Flags().IntVarP(&browserConcurrency, "browser-concurrency", "C", 1, "Number of browsers to use to take screenshots concurrently.")
 	apiCmd.Flags().IntVarP(&pageConcurrency, "page-concurrency", "c", 1, "Number of pages to use to take screenshots concurrently.")
 	apiCmd.Flags().IntVarP(&browserRetakeTimeout, "browser-retake-timeout", "r", 5, "Timeout used when taking a browser from the pool.")
 	apiCmd.Flags().IntVarP(&pageRetakeTimeout, "page-retake-timeout", "t", 5, "Timeout used when taking a page from the pool.")
 	apiCmd.Flags().IntVarP(&pageStabilityTimeout, "page-stability-timeout", "s", 3, "Timeout used when waiting for the page to be stable.")
+	apiCmd.Flags().Int64Var(&maxBodySize, "max-body-size", 5<<20, "Maximum size in bytes of a request body, such as an HTML document to render.")
+	apiCmd.Flags().IntVar(&fullPageMaxHeight, "full-page-max-height", 16384, "Maximum height captured by a full page screenshot.")
+	apiCmd.Flags().BoolVar(&allowPrivateNetworks, "allow-private-networks", false, "Allow pages to reach private, loopback, link-local and reserved addresses.")
+	apiCmd.Flags().StringVar(&allowedHosts, "allowed-hosts", "", "Hosts reachable by pages whatever they resolve to. Use commas to separate multiple hosts.")
+	apiCmd.Flags().StringVar(&deniedHosts, "denied-hosts", "", "Hosts never reachable by pages. Use commas to separate multiple hosts.")
+	apiCmd.Flags().StringVar(&allowedNetworks, "allowed-networks", "", "CIDRs reachable by pages even though they are private (e.g. 10.1.0.0/16). Use commas to separate multiple CIDRs.")
+	apiCmd.Flags().StringVar(&deniedNetworks, "denied-networks", "", "CIDRs never reachable by pages. Use commas to separate multiple CIDRs.")
+	apiCmd.Flags().StringVar(&allowedDomains, "allowed-domains", "", "Domain patterns (e.g. *.example.com) of the only sites rendered. Use commas to separate multiple patterns.")
+	apiCmd.Flags().StringVar(&deniedDomains, "denied-domains", "", "Domain patterns (e.g. *.example.com) of the sites never rendered. Use commas to separate multiple patterns.")
+	apiCmd.Flags().BoolVar(&blockAds, "block-ads", false, "Block the requests to known ad and tracker domains by default.")
+	apiCmd.Flags().StringVar(&blockResourceTypes, "block-resource-types", "", "Types of resources (image, media, font or stylesheet) blocked by default. Use commas to separate multiple types.")
+	apiCmd.Flags().StringVar(&blockURLPatterns, "block-url-patterns", "", "Patterns of URLs (e.g. *://*.example.com/ads/*) blocked by default. Use commas to separate multiple patterns.")
+	apiCmd.Flags().IntVar(&jobWorkers, "job-workers", 1, "Number of asynchronous jobs taking screenshots concurrently.")
+	apiCmd.Flags().IntVar(&jobQueueLength, "job-queue-length", 100, "Number of asynchronous jobs waiting for a worker before new ones are rejected.")
+	apiCmd.Flags().IntVar(&jobRetention, "job-retention", 600, "Duration in seconds finished asynchronous jobs and their result are kept for.")
+	apiCmd.Flags().StringVar(&callbackSecret, "callback-secret", "", "Secret signing the callbacks of asynchronous jobs, callbacks are disabled when empty.")
+	apiCmd.Flags().IntVar(&callbackMaxAttempts, "callback-max-attempts", 5, "Number of attempts to deliver a callback before giving up.")
+	apiCmd.Flags().IntVar(&callbackTimeout, "callback-timeout", 10, "Timeout in seconds of each attempt to deliver a callback.")
+	apiCmd.Flags().IntVar(&callbackWorkers, "callback-workers", 4, "Number of callbacks delivered concurrently.")
+	apiCmd.Flags().StringVar(&storageDirectory, "storage-directory", "", "Directory screenshots are written to with store=true.")
+	apiCmd.Flags().StringVar(&storagePublicURL, "storage-public-url", "", "URL the storage directory (required) or bucket (presigned URLs are returned otherwise) is served at.")
+	apiCmd.Flags().StringVar(&s3Endpoint, "s3-endpoint", "s3.amazonaws.com", "Endpoint of the S3-compatible service, such as localhost:9000 for MinIO.")
+	apiCmd.Flags().StringVar(&s3Bucket, "s3-bucket", "", "Bucket screenshots are written to with store=true. Credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.")
+	apiCmd.Flags().StringVar(&s3Region, "s3-region", "", "Region of the bucket, detected when empty.")
+	apiCmd.Flags().StringVar(&s3Prefix, "s3-prefix", "", "Prefix of the keys of the screenshots written to the bucket.")
+	apiCmd.Flags().BoolVar(&s3Insecure, "s3-insecure", false, "Connect to the S3-compatible service without TLS.")
+	apiCmd.Flags().IntVar(&s3PresignExpiry, "s3-presign-expiry", 3600, "Validity in seconds of the presigned URLs of the screenshots written to the bucket.")
+	apiCmd.Flags().BoolVar(&coalesceRequests, "coalesce-requests", true, "Share a single render between identical screenshots requested concurrently.")
+	apiCmd.Flags().IntVar(&cacheTTL, "cache-ttl", 0, "Duration in seconds screenshots are cached for, by their URL and options. Screenshots are not cached when 0.")
+	apiCmd.Flags().Int64Var(&cacheMemorySize, "cache-memory-size", 64<<20, "Maximum size in bytes of the screenshots cached in memory.")
+	apiCmd.Flags().StringVar(&cacheDirectory, "cache-directory", "", "Directory of the screenshots cached on disk once evicted from memory, disabled when empty.")
+	apiCmd.Flags().BoolVar(&isolatedPages, "isolated-pages", true, "Run every screenshot in its own incognito browser context, sharing no cookies, storage or cache with the others.")
 }
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// pdfCmd represents the pdf command
+var pdfCmd = &cobra.Command{
+	Use:   "pdf [URL]",
+	Short: "Render an URL as a PDF document.",
+	Long:  `Render an URL as a PDF document.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		url := args[0]
+
+		// Create a single rodent mischief (which can just be named a rodent then)
+		mischiefOpts := []mischief.MischiefOpt{
+			// The CLI runs on behalf of its user, who can already reach these networks
+			mischief.WithNetworkPolicy(mischief.NetworkPolicy{AllowPrivateNetworks: true}),
+		}
+
+		browser, _ := cmd.Flags().GetString("browser")
+		if browser != "" {
+			mischiefOpts = append(mischiefOpts, mischief.WithExternalBrowsers([]string{browser}))
+		}
+
+		rodent, err := mischief.New(mischiefOpts...)
+		if err != nil {
+			panic(err)
+		}
+
+		// Render the URL as a PDF
+		paper, _ := cmd.Flags().GetString("paper")
+		size, err := mischief.ParsePaperSize(paper)
+		if err != nil {
+			panic(err)
+		}
+
+		opts := []mischief.PDFOpt{
+			mischief.WithPaperSize(size),
+		}
+
+		if cmd.Flags().Changed("margin") {
+			margin, _ := cmd.Flags().GetFloat64("margin")
+			opts = append(opts, mischief.WithMargins(mischief.Margins{
+				Top:    margin,
+				Right:  margin,
+				Bottom: margin,
+				Left:   margin,
+			}))
+		}
+
+		landscape, _ := cmd.Flags().GetBool("landscape")
+		if landscape {
+			opts = append(opts, mischief.WithLandscape())
+		}
+
+		printBackground, _ := cmd.Flags().GetBool("print-background")
+		if printBackground {
+			opts = append(opts, mischief.WithPrintBackground())
+		}
+
+		header, _ := cmd.Flags().GetString("header-template")
+		footer, _ := cmd.Flags().GetString("footer-template")
+		opts = append(opts, mischief.WithHeaderFooter(header, footer))
+
+		pdf, err := rodent.RenderPDF(cmd.Context(), url, opts...)
+		if err != nil {
+			panic(err)
+		}
+
+		// Save the PDF to a file
+		output, _ := cmd.Flags().GetString("output")
+		err = os.WriteFile(output, pdf, 0644)
+		if err != nil {
+			panic(err)
+		}
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	rootCmd.AddCommand(pdfCmd)
+
+	pdfCmd.Flags().StringP("output", "o", "document.pdf", "Output file for the PDF document")
+	pdfCmd.Flags().StringP("browser", "b", "", "URL to connect to an external browser (e.g. http://localhost:9222) instead of launching a local one")
+	pdfCmd.Flags().String("paper", "letter", "Paper size (letter, legal, tabloid, ledger, a0 to a6)")
+	pdfCmd.Flags().Float64("margin", 0.4, "Margin of every side of the pages in inches")
+	pdfCmd.Flags().Bool("landscape", false, "Print the pages in landscape orientation")
+	pdfCmd.Flags().Bool("print-background", false, "Print the background graphics")
+	pdfCmd.Flags().String("header-template", "", "HTML template of the header of every page")
+	pdfCmd.Flags().String("footer-template", "", "HTML template of the footer of every page")
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yyewolf/rodent/mischief"
@@ -16,19 +19,132 @@ var screenshotCmd = &cobra.Command{
 		url := args[0]
 
 		// Create a single rodent mischief (which can just be named a rodent then)
-		rodent, err := mischief.New()
+		fullPageMaxHeight, _ := cmd.Flags().GetInt("full-page-max-height")
+
+		mischiefOpts := []mischief.MischiefOpt{
+			mischief.WithFullPageMaxHeight(fullPageMaxHeight),
+			// The CLI runs on behalf of its user, who can already reach these networks
+			mischief.WithNetworkPolicy(mischief.NetworkPolicy{AllowPrivateNetworks: true}),
+		}
+
+		browser, _ := cmd.Flags().GetString("browser")
+		if browser != "" {
+			mischiefOpts = append(mischiefOpts, mischief.WithExternalBrowsers([]string{browser}))
+		}
+
+		rodent, err := mischief.New(mischiefOpts...)
 		if err != nil {
 			panic(err)
 		}
 
 		// Take a screenshot of the URL
-		screenshot, err := rodent.TakeScreenshot(url)
+		options := mischief.ScreenshotOptions{
+			URL: url,
+		}
+
+		options.Viewport.Width, _ = cmd.Flags().GetInt("width")
+		options.Viewport.Height, _ = cmd.Flags().GetInt("height")
+		options.Viewport.DeviceScaleFactor, _ = cmd.Flags().GetFloat64("scale")
+		options.Viewport.Mobile, _ = cmd.Flags().GetBool("mobile")
+		options.Viewport.Touch, _ = cmd.Flags().GetBool("touch")
+		options.FullPage, _ = cmd.Flags().GetBool("full-page")
+		options.Selector, _ = cmd.Flags().GetString("selector")
+		options.Quality, _ = cmd.Flags().GetInt("quality")
+
+		// Headers are given as "Name: value", cookies as "name=value" and credentials as "username:password"
+		headers, _ := cmd.Flags().GetStringArray("header")
+		for _, header := range headers {
+			name, value, ok := strings.Cut(header, ":")
+			if !ok {
+				panic("invalid header " + header + ", expected \"Name: value\"")
+			}
+
+			if options.Headers == nil {
+				options.Headers = map[string]string{}
+			}
+			options.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+
+		cookies, _ := cmd.Flags().GetStringArray("cookie")
+		for _, cookie := range cookies {
+			name, value, ok := strings.Cut(cookie, "=")
+			if !ok {
+				panic("invalid cookie " + cookie + ", expected \"name=value\"")
+			}
+
+			options.Cookies = append(options.Cookies, mischief.Cookie{Name: name, Value: value})
+		}
+
+		basicAuth, _ := cmd.Flags().GetString("basic-auth")
+		if basicAuth != "" {
+			username, password, _ := strings.Cut(basicAuth, ":")
+			options.BasicAuth = &mischief.BasicAuth{Username: username, Password: password}
+		}
+
+		steps, _ := cmd.Flags().GetString("steps")
+		if steps != "" {
+			err = json.Unmarshal([]byte(steps), &options.Steps)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		options.EvaluateOnNewDocument, _ = cmd.Flags().GetString("evaluate-on-new-document")
+		options.Script, _ = cmd.Flags().GetString("script")
+		options.ScriptTimeout, _ = cmd.Flags().GetInt("script-timeout")
+
+		options.HideCookieBanners, _ = cmd.Flags().GetBool("hide-cookie-banners")
+		options.HideSelectors, _ = cmd.Flags().GetStringArray("hide-selector")
+		options.CSS, _ = cmd.Flags().GetString("css")
+
+		blockAds, _ := cmd.Flags().GetBool("block-ads")
+		blockResourceTypes, _ := cmd.Flags().GetString("block-resource-types")
+		blockURLPatterns, _ := cmd.Flags().GetString("block-url-patterns")
+		blocking := resourceBlocking(blockAds, blockResourceTypes, blockURLPatterns)
+		options.Blocking = &blocking
+
+		// Waits are run in a fixed order: wait-until, wait-for-selector, wait-for-expression then delay
+		waitUntil, _ := cmd.Flags().GetString("wait-until")
+		if waitUntil != "" {
+			options.Waits = append(options.Waits, mischief.Wait{Type: mischief.WaitType(waitUntil)})
+		}
+
+		waitForSelector, _ := cmd.Flags().GetString("wait-for-selector")
+		if waitForSelector != "" {
+			options.Waits = append(options.Waits, mischief.Wait{Type: mischief.WaitSelector, Selector: waitForSelector})
+		}
+
+		waitForExpression, _ := cmd.Flags().GetString("wait-for-expression")
+		if waitForExpression != "" {
+			options.Waits = append(options.Waits, mischief.Wait{Type: mischief.WaitExpression, Expression: waitForExpression})
+		}
+
+		delay, _ := cmd.Flags().GetInt("delay")
+		if delay > 0 {
+			options.Waits = append(options.Waits, mischief.Wait{Type: mischief.WaitDelay, Duration: delay})
+		}
+
+		// Use the format of the flag, or guess it from the output file extension
+		output, _ := cmd.Flags().GetString("output")
+		formatName, _ := cmd.Flags().GetString("format")
+
+		options.Format, err = mischief.ParseFormat(formatName)
+		if formatName == "" {
+			options.Format, err = mischief.ParseFormat(strings.TrimPrefix(filepath.Ext(output), "."))
+			if err != nil {
+				options.Format, err = mischief.FormatPNG, nil
+			}
+		}
+		if err != nil {
+			panic(err)
+		}
+
+		screenshot, err := rodent.TakeScreenshot(cmd.Context(), options)
 		if err != nil {
 			panic(err)
 		}
 
 		// Save the screenshot to a file
-		output, _ := cmd.Flags().GetString("output")
 		err = os.WriteFile(output, screenshot, 0644)
 		if err != nil {
 			panic(err)
@@ -49,4 +165,32 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	screenshotCmd.Flags().StringP("output", "o", "screenshot.png", "Output file for the screenshot")
+	screenshotCmd.Flags().StringP("browser", "b", "", "URL to connect to an external browser (e.g. http://localhost:9222) instead of launching a local one")
+	screenshotCmd.Flags().Int("width", 0, "Width of the viewport in CSS pixels (0 keeps the default)")
+	screenshotCmd.Flags().Int("height", 0, "Height of the viewport in CSS pixels (0 keeps the default)")
+	screenshotCmd.Flags().Float64("scale", 0, "Device scale factor of the viewport (0 keeps the default)")
+	screenshotCmd.Flags().Bool("mobile", false, "Emulate a mobile device")
+	screenshotCmd.Flags().Bool("touch", false, "Enable touch events emulation")
+	screenshotCmd.Flags().Bool("full-page", false, "Capture the whole scrollable document instead of the viewport")
+	screenshotCmd.Flags().String("selector", "", "CSS selector of the element to capture instead of the viewport")
+	screenshotCmd.Flags().StringP("format", "f", "", "Image format (png, jpeg or webp), guessed from the output file when omitted")
+	screenshotCmd.Flags().IntP("quality", "q", 0, "Compression quality (1-100) for jpeg and webp")
+	screenshotCmd.Flags().StringArray("header", nil, "Extra HTTP header sent with every request of the page, as \"Name: value\" (repeatable)")
+	screenshotCmd.Flags().StringArray("cookie", nil, "Cookie set for the page before loading it, as \"name=value\" (repeatable)")
+	screenshotCmd.Flags().String("basic-auth", "", "HTTP basic authentication credentials sent to the page, as \"username:password\"")
+	screenshotCmd.Flags().String("steps", "", "JSON list of interaction steps run after the waits, e.g. '[{\"type\":\"click\",\"selector\":\"#more\"}]'")
+	screenshotCmd.Flags().String("evaluate-on-new-document", "", "Script evaluated in every document of the page, before its own scripts")
+	screenshotCmd.Flags().String("script", "", "Body of an async function evaluated in the page after the waits")
+	screenshotCmd.Flags().Int("script-timeout", 0, "Timeout of the script in milliseconds (0 uses the page stability timeout)")
+	screenshotCmd.Flags().Bool("hide-cookie-banners", false, "Accept and hide the cookie banners of common consent management platforms")
+	screenshotCmd.Flags().StringArray("hide-selector", nil, "CSS selector of elements hidden before capturing (repeatable)")
+	screenshotCmd.Flags().String("css", "", "Style sheet injected in the page before capturing")
+	screenshotCmd.Flags().Bool("block-ads", false, "Block the requests to known ad and tracker domains")
+	screenshotCmd.Flags().String("block-resource-types", "", "Types of resources (image, media, font or stylesheet) blocked, separated by commas")
+	screenshotCmd.Flags().String("block-url-patterns", "", "Patterns of URLs (e.g. *://*.example.com/ads/*) blocked, separated by commas")
+	screenshotCmd.Flags().String("wait-until", "", "Wait for the page to be loaded (load), the network to be idle (networkIdle) or the DOM to be stable (domStable)")
+	screenshotCmd.Flags().String("wait-for-selector", "", "CSS selector of an element to wait for before capturing")
+	screenshotCmd.Flags().String("wait-for-expression", "", "JavaScript expression to wait to be truthy before capturing")
+	screenshotCmd.Flags().Int("delay", 0, "Delay in milliseconds to wait before capturing")
+	screenshotCmd.Flags().Int("full-page-max-height", 16384, "Maximum height captured in full page mode")
 }
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/yyewolf/rodent/mischief"
+	"github.com/yyewolf/rodent/storage"
+)
+
+// splitList splits a comma separated flag value, ignoring empty items.
+func splitList(value string) []string {
+	var items []string
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// resourceBlocking builds the resource blocking from the values of the blocking flags.
+func resourceBlocking(ads bool, resourceTypes string, urlPatterns string) mischief.ResourceBlocking {
+	blocking := mischief.ResourceBlocking{
+		Ads:         ads,
+		URLPatterns: splitList(urlPatterns),
+	}
+
+	for _, resourceType := range splitList(resourceTypes) {
+		blocking.ResourceTypes = append(blocking.ResourceTypes, mischief.ResourceType(resourceType))
+	}
+
+	return blocking
+}
+
+// newStorage builds the storage from the values of the storage flags,
+// nil when none is configured.
+func newStorage() (storage.Storage, error) {
+	if storageDirectory != "" && s3Bucket != "" {
+		return nil, errors.New("--storage-directory and --s3-bucket cannot be used together")
+	}
+
+	if storageDirectory != "" {
+		if storagePublicURL == "" {
+			return nil, errors.New("--storage-directory requires --storage-public-url")
+		}
+
+		return storage.NewFilesystem(storageDirectory, storagePublicURL)
+	}
+
+	if s3Bucket != "" {
+		opts := []storage.S3Opt{
+			storage.WithS3Region(s3Region),
+			storage.WithS3Prefix(s3Prefix),
+			storage.WithS3Insecure(s3Insecure),
+			storage.WithS3PresignExpiry(time.Duration(s3PresignExpiry) * time.Second),
+		}
+		if storagePublicURL != "" {
+			opts = append(opts, storage.WithS3PublicURL(storagePublicURL))
+		}
+
+		return storage.NewS3(s3Endpoint, s3Bucket, opts...)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,15 @@
+package jobs
+
+import "errors"
+
+var (
+	ErrJobNotFound       = errors.New("job not found")
+	ErrJobNotFinished    = errors.New("job is not finished")
+	ErrJobFailed         = errors.New("job failed")
+	ErrQueueFull         = errors.New("job queue is full")
+	ErrQueueStopped      = errors.New("job queue is stopped")
+	ErrNoCallbacks       = errors.New("callbacks are not enabled")
+	ErrCallbackQueueFull = errors.New("callback queue is full")
+	ErrNoStorage         = errors.New("no storage is configured")
+	ErrResultStored      = errors.New("job result was written to the storage")
+)
@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/yyewolf/rodent/mischief"
+	"github.com/yyewolf/rodent/storage"
+	"github.com/yyewolf/rodent/webhook"
+)
+
+// Status is the state of a job in the queue.
+type Status string
+
+const (
+	// StatusQueued is the status of a job waiting for a worker
+	StatusQueued Status = "queued"
+	// StatusRunning is the status of a job taken by a worker
+	StatusRunning Status = "running"
+	// StatusSucceeded is the status of a job whose result is available
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed is the status of a job that failed
+	StatusFailed Status = "failed"
+)
+
+// IsFinished reports whether the job will not change anymore.
+func (s Status) IsFinished() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+// Job is a screenshot taken asynchronously by the queue.
+//
+// Jobs returned by the queue are snapshots, they do not change
+// when the job progresses.
+type Job struct {
+	ID         string     `json:"id" description:"Identifier of the job" example:"3f2a6c1e9b0d4e7a8c5f1b2d3e4a5b6c"`
+	Status     Status     `json:"status" description:"Status of the job (queued, running, succeeded or failed)" example:"succeeded"`
+	Error      string     `json:"error,omitempty" description:"Error of the job when it failed"`
+	CreatedAt  time.Time  `json:"createdAt" description:"Date the job was enqueued"`
+	StartedAt  *time.Time `json:"startedAt,omitempty" description:"Date the job was taken by a worker"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty" description:"Date the job finished"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" description:"Date the finished job and its result are forgotten"`
+
+	Object *storage.Object `json:"object,omitempty" description:"Object the result was written to, when the job requested to store it"`
+
+	CallbackURL         string     `json:"callbackUrl,omitempty" description:"URL notified when the job is finished" example:"https://example.com/hooks/rodent"`
+	CallbackDeliveredAt *time.Time `json:"callbackDeliveredAt,omitempty" description:"Date the callback was accepted by its receiver"`
+	CallbackError       string     `json:"callbackError,omitempty" description:"Error of the callback when it could not be delivered"`
+
+	options mischief.ScreenshotOptions
+	store   bool
+	result  []byte
+	err     error
+}
+
+// Request is a screenshot to enqueue.
+type Request struct {
+	mischief.ScreenshotOptions
+
+	// CallbackURL is the URL notified, with a signed payload, when the job is finished
+	CallbackURL string `json:"callbackUrl,omitempty" description:"URL notified with a signed payload, holding the image or the error, when the job is finished" example:"https://example.com/hooks/rodent"`
+	// Store writes the result to the storage instead of keeping it in the queue
+	Store bool `json:"store,omitempty" description:"Write the result to the configured storage instead of keeping it in the queue"`
+}
+
+// Options returns the screenshot options of the job.
+func (j Job) Options() mischief.ScreenshotOptions {
+	return j.options
+}
+
+// Result returns the screenshot of a succeeded job, or the error explaining
+// why it is not available, such as ErrResultStored when it is in the storage.
+func (j Job) Result() ([]byte, error) {
+	switch j.Status {
+	case StatusSucceeded:
+		if j.Object != nil {
+			return nil, ErrResultStored
+		}
+
+		return j.result, nil
+	case StatusFailed:
+		return nil, j.err
+	default:
+		return nil, ErrJobNotFinished
+	}
+}
+
+// payload describes the finished job to its callback.
+func (j Job) payload() webhook.Payload {
+	payload := webhook.Payload{
+		ID:        j.ID,
+		Status:    string(j.Status),
+		Error:     j.Error,
+		URL:       j.options.URL,
+		CreatedAt: j.CreatedAt,
+	}
+
+	if j.StartedAt != nil {
+		payload.StartedAt = *j.StartedAt
+	}
+
+	if j.FinishedAt != nil {
+		payload.FinishedAt = *j.FinishedAt
+	}
+
+	// Stored results are referenced rather than embedded
+	if j.Status == StatusSucceeded && j.Object != nil {
+		payload.ContentType = j.Object.ContentType
+		payload.Object = j.Object
+	} else if j.Status == StatusSucceeded {
+		payload.ContentType = j.options.Format.ContentType()
+		payload.Image = j.result
+	}
+
+	return payload
+}
+
+// newID returns a random job identifier.
+func newID() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,367 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yyewolf/rodent/mischief"
+	"github.com/yyewolf/rodent/storage"
+	"github.com/yyewolf/rodent/webhook"
+)
+
+// Queue takes screenshots asynchronously with a fixed number of workers
+// in front of a Mischief instance.
+//
+// Finished jobs, and their result, are kept for the retention duration
+// so that they can be polled.
+type Queue struct {
+	// mischief takes the screenshots
+	mischief screenshotter
+	// logger is the logger of the queue
+	logger *slog.Logger
+	// webhook sends the callbacks of the jobs, nil when they are not enabled
+	webhook *webhook.Sender
+	// storage is where results are written when jobs request it, nil when not configured
+	storage storage.Storage
+
+	// workers is the number of jobs run concurrently
+	workers int
+	// length is the number of jobs waiting for a worker
+	length int
+	// retention is the duration finished jobs are kept for
+	retention time.Duration
+	// callbackWorkers is the number of callbacks delivered concurrently
+	callbackWorkers int
+
+	// pending holds the jobs waiting for a worker
+	pending chan *Job
+	// callbacks holds the finished jobs waiting for their callback to be delivered
+	callbacks chan *Job
+
+	// mu guards the jobs and their fields
+	mu   sync.RWMutex
+	jobs map[string]*Job
+
+	// ctx is cancelled when the queue is stopped
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type QueueOpt func(*Queue)
+
+// screenshotter takes the screenshots, a Mischief instance outside of the tests.
+type screenshotter interface {
+	TakeScreenshot(ctx context.Context, options mischief.ScreenshotOptions) ([]byte, error)
+}
+
+// New creates a new Queue instance and starts its workers.
+//
+// Example (and default values):
+//
+//	q := jobs.New(m,
+//		jobs.WithWorkers(1),
+//		jobs.WithQueueLength(100),
+//		jobs.WithRetention(10*time.Minute),
+//		jobs.WithCallbackWorkers(4),
+//		jobs.WithLogger(slog.Default()),
+//	)
+func New(mischief *mischief.Mischief, opts ...QueueOpt) *Queue {
+	return newQueue(mischief, opts...)
+}
+
+// newQueue creates a new Queue instance taking the screenshots
+// with the screenshotter and starts its workers.
+func newQueue(mischief screenshotter, opts ...QueueOpt) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q := &Queue{
+		mischief: mischief,
+		jobs:     map[string]*Job{},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	var defaultOpts = []QueueOpt{
+		WithWorkers(1),
+		WithQueueLength(100),
+		WithRetention(10 * time.Minute),
+		WithCallbackWorkers(4),
+		WithLogger(slog.Default()),
+	}
+
+	opts = append(defaultOpts, opts...)
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	q.pending = make(chan *Job, q.length)
+	q.callbacks = make(chan *Job, q.length)
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+
+	if q.webhook != nil {
+		for i := 0; i < q.callbackWorkers; i++ {
+			q.wg.Add(1)
+			go q.deliver()
+		}
+	}
+
+	q.wg.Add(1)
+	go q.expire()
+
+	return q
+}
+
+// Enqueue adds a screenshot to the queue and returns its job.
+//
+// The options and the callback URL are validated right away, so that
+// they are reported to the caller instead of failing the job.
+func (q *Queue) Enqueue(ctx context.Context, request Request) (Job, error) {
+	if q.ctx.Err() != nil {
+		return Job{}, ErrQueueStopped
+	}
+
+	err := request.Validate()
+	if err != nil {
+		return Job{}, err
+	}
+
+	if request.Store && q.storage == nil {
+		return Job{}, ErrNoStorage
+	}
+
+	if request.CallbackURL != "" {
+		if q.webhook == nil {
+			return Job{}, ErrNoCallbacks
+		}
+
+		err = q.webhook.Check(ctx, request.CallbackURL)
+		if err != nil {
+			return Job{}, err
+		}
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	job := &Job{
+		ID:          id,
+		Status:      StatusQueued,
+		CreatedAt:   time.Now(),
+		CallbackURL: request.CallbackURL,
+		options:     request.ScreenshotOptions,
+		store:       request.Store,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case q.pending <- job:
+	default:
+		return Job{}, ErrQueueFull
+	}
+
+	q.jobs[id] = job
+
+	q.logger.Info("job enqueued", slog.Any("id", id))
+
+	return *job, nil
+}
+
+// Get returns a snapshot of the job.
+func (q *Queue) Get(id string) (Job, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+
+	return *job, nil
+}
+
+// Stop stops the workers, the running jobs and callbacks are cancelled
+// and the queued ones are never run nor delivered.
+func (q *Queue) Stop() {
+	q.cancel()
+	q.wg.Wait()
+}
+
+// work runs the queued jobs until the queue is stopped.
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case job := <-q.pending:
+			q.run(job)
+			q.dispatch(job)
+		}
+	}
+}
+
+// deliver delivers the callbacks of the finished jobs until the queue is stopped.
+func (q *Queue) deliver() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case job := <-q.callbacks:
+			q.notify(job)
+		}
+	}
+}
+
+// run takes the screenshot of the job and records its outcome.
+func (q *Queue) run(job *Job) {
+	q.mu.Lock()
+	startedAt := time.Now()
+	job.Status = StatusRunning
+	job.StartedAt = &startedAt
+	q.mu.Unlock()
+
+	result, err := q.mischief.TakeScreenshot(q.ctx, job.options)
+
+	var object *storage.Object
+	if err == nil && job.store {
+		object, err = q.put(job, result)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	finishedAt := time.Now()
+	expiresAt := finishedAt.Add(q.retention)
+	job.FinishedAt = &finishedAt
+	job.ExpiresAt = &expiresAt
+
+	if err != nil {
+		q.logger.Warn("job failed", slog.Any("id", job.ID), slog.Any("error", err))
+
+		job.Status = StatusFailed
+		job.Error = err.Error()
+		job.err = errors.Join(ErrJobFailed, err)
+
+		// The details, such as resolved addresses, are not disclosed to the client
+		if errors.Is(err, mischief.ErrBlockedByNetworkPolicy) {
+			job.Error = mischief.ErrBlockedByNetworkPolicy.Error()
+			job.err = errors.Join(ErrJobFailed, mischief.ErrBlockedByNetworkPolicy)
+		}
+		return
+	}
+
+	q.logger.Info("job succeeded", slog.Any("id", job.ID), slog.Any("duration", finishedAt.Sub(startedAt)))
+
+	job.Status = StatusSucceeded
+
+	// Stored results are not kept in the queue
+	if object != nil {
+		job.Object = object
+		return
+	}
+
+	job.result = result
+}
+
+// put writes the result of the job to the storage.
+func (q *Queue) put(job *Job, result []byte) (*storage.Object, error) {
+	key, err := storage.NewKey(job.options.Format.Extension())
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := q.storage.Put(q.ctx, key, result, job.options.Format.ContentType())
+	if err != nil {
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+// dispatch hands the finished job over to the callback workers when it has
+// a callback, so that a slow receiver does not hold the worker back.
+//
+// The callback is given up when too many are waiting to be delivered.
+func (q *Queue) dispatch(job *Job) {
+	q.mu.RLock()
+	callbackURL := job.CallbackURL
+	q.mu.RUnlock()
+
+	if callbackURL == "" {
+		return
+	}
+
+	select {
+	case q.callbacks <- job:
+	default:
+		q.logger.Warn("job callback dropped", slog.Any("id", job.ID), slog.Any("error", ErrCallbackQueueFull))
+
+		q.mu.Lock()
+		job.CallbackError = ErrCallbackQueueFull.Error()
+		q.mu.Unlock()
+	}
+}
+
+// notify delivers the callback of the finished job.
+func (q *Queue) notify(job *Job) {
+	q.mu.RLock()
+	callbackURL := job.CallbackURL
+	payload := job.payload()
+	q.mu.RUnlock()
+
+	err := q.webhook.Deliver(q.ctx, callbackURL, payload)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err != nil {
+		q.logger.Warn("job callback failed", slog.Any("id", job.ID), slog.Any("error", err))
+
+		job.CallbackError = err.Error()
+		return
+	}
+
+	deliveredAt := time.Now()
+	job.CallbackDeliveredAt = &deliveredAt
+}
+
+// expire forgets the finished jobs once their retention is over.
+func (q *Queue) expire() {
+	defer q.wg.Done()
+
+	interval := min(q.retention, time.Minute)
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case now := <-time.After(interval):
+			q.mu.Lock()
+			for id, job := range q.jobs {
+				if job.ExpiresAt != nil && now.After(*job.ExpiresAt) {
+					delete(q.jobs, id)
+				}
+			}
+			q.mu.Unlock()
+		}
+	}
+}
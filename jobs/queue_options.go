@@ -0,0 +1,121 @@
+package jobs
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/yyewolf/rodent/storage"
+	"github.com/yyewolf/rodent/webhook"
+)
+
+// WithWorkers is an option to set the number of jobs run concurrently.
+//
+// Jobs still wait for a browser of the Mischief instance, this should
+// not exceed its total concurrency.
+//
+// By default, this is set to 1.
+//
+// Example:
+//
+//	q := jobs.New(m,
+//		jobs.WithWorkers(4),
+//	)
+func WithWorkers(workers int) QueueOpt {
+	return func(q *Queue) {
+		q.workers = workers
+	}
+}
+
+// WithQueueLength is an option to set the number of jobs waiting
+// for a worker, further jobs being rejected.
+//
+// By default, this is set to 100.
+//
+// Example:
+//
+//	q := jobs.New(m,
+//		jobs.WithQueueLength(1000),
+//	)
+func WithQueueLength(length int) QueueOpt {
+	return func(q *Queue) {
+		q.length = length
+	}
+}
+
+// WithRetention is an option to set the duration finished jobs,
+// and their result, are kept for.
+//
+// By default, this is set to 10 minutes.
+//
+// Example:
+//
+//	q := jobs.New(m,
+//		jobs.WithRetention(time.Hour),
+//	)
+func WithRetention(retention time.Duration) QueueOpt {
+	return func(q *Queue) {
+		q.retention = retention
+	}
+}
+
+// WithWebhook is an option to set the sender of the callbacks of the jobs.
+//
+// By default, no sender is set and jobs with a callback URL are rejected.
+//
+// Example:
+//
+//	q := jobs.New(m,
+//		jobs.WithWebhook(sender),
+//	)
+func WithWebhook(sender *webhook.Sender) QueueOpt {
+	return func(q *Queue) {
+		q.webhook = sender
+	}
+}
+
+// WithCallbackWorkers is an option to set the number of callbacks
+// delivered concurrently, apart from the workers taking the screenshots.
+//
+// By default, this is set to 4.
+//
+// Example:
+//
+//	q := jobs.New(m,
+//		jobs.WithCallbackWorkers(8),
+//	)
+func WithCallbackWorkers(workers int) QueueOpt {
+	return func(q *Queue) {
+		q.callbackWorkers = workers
+	}
+}
+
+// WithStorage is an option to set the storage the results of the jobs
+// are written to when they request it.
+//
+// By default, no storage is set and jobs requesting it are rejected.
+//
+// Example:
+//
+//	q := jobs.New(m,
+//		jobs.WithStorage(s),
+//	)
+func WithStorage(storage storage.Storage) QueueOpt {
+	return func(q *Queue) {
+		q.storage = storage
+	}
+}
+
+// WithLogger is an option to set the logger of the queue.
+//
+// By default, the logger is set to slog.Default().
+//
+// Example:
+//
+//	q := jobs.New(m,
+//		jobs.WithLogger(slog.Default()),
+//	)
+func WithLogger(logger *slog.Logger) QueueOpt {
+	return func(q *Queue) {
+		q.logger = logger
+	}
+}
@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+var errRender = errors.New("render failed")
+
+// stubScreenshotter renders until released, failing the URLs of fails.
+type stubScreenshotter struct {
+	release chan struct{}
+	// started receives the URL of every screenshot once it started
+	started chan string
+	fails   map[string]bool
+}
+
+func newStubScreenshotter() *stubScreenshotter {
+	return &stubScreenshotter{
+		release: make(chan struct{}),
+		started: make(chan string, 100),
+		fails:   map[string]bool{},
+	}
+}
+
+func (s *stubScreenshotter) TakeScreenshot(ctx context.Context, options mischief.ScreenshotOptions) ([]byte, error) {
+	s.started <- options.URL
+
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if s.fails[options.URL] {
+		return nil, errRender
+	}
+
+	return []byte(options.URL), nil
+}
+
+// newTestQueue creates a queue taking the screenshots with the stub, stopped at the end of the test.
+func newTestQueue(t *testing.T, stub *stubScreenshotter, opts ...QueueOpt) *Queue {
+	t.Helper()
+
+	defaultOpts := []QueueOpt{
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}
+
+	q := newQueue(stub, append(defaultOpts, opts...)...)
+	t.Cleanup(q.Stop)
+
+	return q
+}
+
+// request returns a request for a screenshot of the URL.
+func request(url string) Request {
+	return Request{ScreenshotOptions: mischief.ScreenshotOptions{URL: url}}
+}
+
+// waitForStatus waits until the job has the status, and returns it.
+func waitForStatus(t *testing.T, q *Queue, id string, status Status) Job {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := q.Get(id)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+
+		if job.Status == status {
+			return job
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("job never had status %s", status)
+	return Job{}
+}
+
+func TestQueueEnqueueValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		request Request
+		wantErr error
+	}{
+		{"invalid options", Request{}, mischief.ErrInvalidSource},
+		{"store without storage", Request{ScreenshotOptions: mischief.ScreenshotOptions{URL: "https://example.com/"}, Store: true}, ErrNoStorage},
+		{"callback without webhook", Request{ScreenshotOptions: mischief.ScreenshotOptions{URL: "https://example.com/"}, CallbackURL: "https://example.com/hook"}, ErrNoCallbacks},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(t, newStubScreenshotter())
+
+			_, err := q.Enqueue(context.Background(), tt.request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Enqueue() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if len(q.jobs) != 0 {
+				t.Errorf("%d jobs in the queue, want 0", len(q.jobs))
+			}
+		})
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	stub := newStubScreenshotter()
+	q := newTestQueue(t, stub, WithWorkers(1), WithQueueLength(1))
+
+	// The first job holds the worker, the second one waits for it
+	_, err := q.Enqueue(context.Background(), request("https://example.com/1"))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	<-stub.started
+
+	_, err = q.Enqueue(context.Background(), request("https://example.com/2"))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	_, err = q.Enqueue(context.Background(), request("https://example.com/3"))
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Enqueue() error = %v, want %v", err, ErrQueueFull)
+	}
+
+	close(stub.release)
+}
+
+func TestQueueStatusTransitions(t *testing.T) {
+	stub := newStubScreenshotter()
+	stub.fails["https://example.com/fail"] = true
+	q := newTestQueue(t, stub, WithWorkers(1))
+
+	succeeding, err := q.Enqueue(context.Background(), request("https://example.com/"))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if succeeding.Status != StatusQueued {
+		t.Errorf("enqueued job status = %s, want %s", succeeding.Status, StatusQueued)
+	}
+
+	<-stub.started
+
+	failing, err := q.Enqueue(context.Background(), request("https://example.com/fail"))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	// The worker is busy with the first job
+	job := waitForStatus(t, q, failing.ID, StatusQueued)
+	if job.StartedAt != nil {
+		t.Errorf("queued job StartedAt = %v, want nil", job.StartedAt)
+	}
+
+	job = waitForStatus(t, q, succeeding.ID, StatusRunning)
+	if job.StartedAt == nil || job.FinishedAt != nil {
+		t.Errorf("running job StartedAt = %v, FinishedAt = %v, want only StartedAt", job.StartedAt, job.FinishedAt)
+	}
+
+	_, err = job.Result()
+	if !errors.Is(err, ErrJobNotFinished) {
+		t.Errorf("running job Result() error = %v, want %v", err, ErrJobNotFinished)
+	}
+
+	close(stub.release)
+
+	job = waitForStatus(t, q, succeeding.ID, StatusSucceeded)
+	if job.FinishedAt == nil || job.ExpiresAt == nil {
+		t.Errorf("succeeded job FinishedAt = %v, ExpiresAt = %v, want both set", job.FinishedAt, job.ExpiresAt)
+	}
+
+	result, err := job.Result()
+	if err != nil || string(result) != "https://example.com/" {
+		t.Errorf("succeeded job Result() = %q, %v, want the screenshot", result, err)
+	}
+
+	job = waitForStatus(t, q, failing.ID, StatusFailed)
+	if job.Error != errRender.Error() {
+		t.Errorf("failed job Error = %q, want %q", job.Error, errRender.Error())
+	}
+
+	_, err = job.Result()
+	if !errors.Is(err, ErrJobFailed) || !errors.Is(err, errRender) {
+		t.Errorf("failed job Result() error = %v, want %v and %v", err, ErrJobFailed, errRender)
+	}
+}
+
+func TestQueueExpiry(t *testing.T) {
+	stub := newStubScreenshotter()
+	close(stub.release)
+	q := newTestQueue(t, stub, WithRetention(20*time.Millisecond))
+
+	job, err := q.Enqueue(context.Background(), request("https://example.com/"))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	waitForStatus(t, q, job.ID, StatusSucceeded)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		_, err = q.Get(job.ID)
+		if errors.Is(err, ErrJobNotFound) {
+			return
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("Get() error = %v after the retention, want %v", err, ErrJobNotFound)
+}
+
+func TestQueueStopped(t *testing.T) {
+	q := newTestQueue(t, newStubScreenshotter())
+	q.Stop()
+
+	_, err := q.Enqueue(context.Background(), request("https://example.com/"))
+	if !errors.Is(err, ErrQueueStopped) {
+		t.Fatalf("Enqueue() error = %v, want %v", err, ErrQueueStopped)
+	}
+}
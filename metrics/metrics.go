@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "rodent"
+
+// Phases of a screenshot, timed by PhaseDuration.
+const (
+	// PhaseGetRat is the wait for a browser of the pool
+	PhaseGetRat = "get_rat"
+	// PhaseGetPage is the checkout of a page of the browser
+	PhaseGetPage = "get_page"
+	// PhaseNavigate is the navigation to the page, or the rendering of its document
+	PhaseNavigate = "navigate"
+	// PhaseWait is the wait for the page to be stable, or the requested waits
+	PhaseWait = "wait"
+	// PhaseCapture is the capture of the screenshot or the PDF
+	PhaseCapture = "capture"
+)
+
+// Registry holds the metrics of Rodent, along with the Go runtime and process ones.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts the requests of the API by route, method and status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of requests handled by the API.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration times the requests of the API by route and method
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the requests handled by the API.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"route", "method"})
+
+	// PhaseDuration times the phases of the screenshots
+	PhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "phase_duration_seconds",
+		Help:      "Duration of the phases of the screenshots: get_rat, get_page, navigate, wait and capture.",
+		Buckets:   []float64{.001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"phase"})
+
+	// Errors counts the failed screenshots and PDFs by sentinel error
+	Errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Number of failed screenshots and PDFs, by sentinel error.",
+	}, []string{"error"})
+
+	// RatPoolSize is the number of slots of the browser pools, a slot per concurrent page
+	RatPoolSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rat_pool_size",
+		Help:      "Number of slots of the browser pools, a slot per concurrent page.",
+	})
+
+	// RatPoolInUse is the number of slots taken from the browser pools
+	RatPoolInUse = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rat_pool_in_use",
+		Help:      "Number of slots taken from the browser pools.",
+	})
+
+	// BrowserRecreations counts the browsers recreated, by reason
+	BrowserRecreations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "browser_recreations_total",
+		Help:      "Number of browsers recreated, by cleanup or after a lost connection.",
+	}, []string{"reason"})
+
+	// ReapedProcesses counts the child processes reaped
+	ReapedProcesses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reaped_processes_total",
+		Help:      "Number of child processes reaped.",
+	})
+
+	// CoalescedRequests counts the screenshots that joined an identical render in flight
+	CoalescedRequests = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "coalesced_requests_total",
+		Help:      "Number of screenshots that joined an identical render in flight.",
+	})
+
+	// CacheRequests counts the cacheable screenshots by result, hit or miss
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Number of cacheable screenshots, by result (hit or miss).",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		PhaseDuration,
+		Errors,
+		RatPoolSize,
+		RatPoolInUse,
+		BrowserRecreations,
+		ReapedProcesses,
+		CoalescedRequests,
+		CacheRequests,
+	)
+}
+
+// ObservePhase records the duration of a phase started at start.
+//
+// Example:
+//
+//	defer metrics.ObservePhase(metrics.PhaseCapture, time.Now())
+func ObservePhase(phase string, start time.Time) {
+	PhaseDuration.WithLabelValues(phase).Observe(time.Since(start).Seconds())
+}
+
+// Handler serves the metrics of the Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/yyewolf/rodent/metrics"
 	"github.com/yyewolf/rodent/rat"
 	"golang.org/x/exp/rand"
 )
@@ -22,6 +23,8 @@ func (mischief *Mischief) clearRat(ctx context.Context, rat *rat.Rat, recreate b
 		if err != nil {
 			return fmt.Errorf("failed to recreate rat: %w", err)
 		}
+
+		metrics.BrowserRecreations.WithLabelValues("cleanup").Inc()
 	} else {
 		err := rat.Close()
 		if err != nil {
@@ -71,6 +74,7 @@ func (mischief *Mischief) Destroy(ctx context.Context) error {
 	}
 
 	mischief.watchratCancel()
+	metrics.RatPoolSize.Sub(float64(mischief.browserConcurrency * mischief.pageConcurrency))
 
 	return nil
 }
@@ -0,0 +1,71 @@
+() => {
+  // Accept buttons of common consent management platforms
+  const selectors = [
+    '#onetrust-accept-btn-handler',
+    '#CybotCookiebotDialogBodyLevelButtonLevelOptinAllowAll',
+    '#CybotCookiebotDialogBodyButtonAccept',
+    '#didomi-notice-agree-button',
+    '.qc-cmp2-summary-buttons button[mode="primary"]',
+    '#truste-consent-button',
+    '.cky-btn-accept',
+    '.osano-cm-accept-all',
+    '.cmplz-accept',
+    '#BorlabsCookieBox a[data-cookie-accept-all]',
+    '.klaro .cm-btn-accept-all',
+    '.iubenda-cs-accept-btn',
+    '.fc-cta-consent',
+    '#cookie_action_close_header',
+    '.cc-allow',
+    '.cc-dismiss',
+  ]
+
+  // Links leaving the page and buttons submitting a form would navigate away
+  // before the capture, only links staying on it, such as href="#", are clicked
+  const navigates = (element) => {
+    const control = element.closest('button, input')
+    if (control && control.form && control.type === 'submit') return true
+
+    const link = element.closest('a[href]')
+    if (!link) return false
+
+    const href = link.getAttribute('href').trim()
+    if (href === '' || href.startsWith('#') || href.toLowerCase().startsWith('javascript:')) return false
+
+    const target = new URL(link.href, document.baseURI)
+    const current = new URL(location.href)
+    target.hash = ''
+    current.hash = ''
+
+    return target.href !== current.href || (link.target !== '' && link.target !== '_self')
+  }
+
+  let clicked = 0
+
+  for (const selector of selectors) {
+    for (const button of document.querySelectorAll(selector)) {
+      if (navigates(button)) continue
+
+      button.click()
+      clicked++
+    }
+  }
+
+  // Buttons of unknown banners, recognized by their text inside a consent looking container
+  const label = /^\s*(accept|accept all|accept all cookies|accept cookies|allow all|allow cookies|agree|i agree|agree and close|got it|ok|tout accepter|accepter|alle akzeptieren|akzeptieren|aceptar|aceptar todo|accetta|accetta tutto)\s*$/i
+  const container = /cookie|consent|gdpr|privacy|cmp/i
+
+  for (const button of document.querySelectorAll('button, [role="button"], input[type="button"]')) {
+    const text = button.innerText || button.value || ''
+    if (!label.test(text) || navigates(button)) continue
+
+    for (let parent = button.parentElement; parent; parent = parent.parentElement) {
+      if (container.test(parent.id) || container.test(parent.className)) {
+        button.click()
+        clicked++
+        break
+      }
+    }
+  }
+
+  return clicked
+}
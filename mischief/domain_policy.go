@@ -0,0 +1,112 @@
+package mischief
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DomainPolicy restricts the sites rendered by their domain names.
+//
+// Patterns are either a domain, matching only this domain, or a wildcard
+// followed by a domain, such as "*.example.com", matching its subdomains
+// but not the domain itself.
+type DomainPolicy struct {
+	// Allowed are the patterns of the only domains rendered, every domain when empty
+	Allowed []string
+	// Denied are the patterns of the domains never rendered
+	Denied []string
+}
+
+// IsZero reports whether the policy renders every domain.
+func (p DomainPolicy) IsZero() bool {
+	return len(p.Allowed) == 0 && len(p.Denied) == 0
+}
+
+// Validate checks that the patterns of the policy are well formed.
+func (p DomainPolicy) Validate() error {
+	for _, pattern := range slices.Concat(p.Allowed, p.Denied) {
+		err := validateDomainPattern(pattern)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Check returns an error wrapping ErrDomainNotAllowed
+// when the host is not allowed to be rendered.
+func (p DomainPolicy) Check(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	for _, pattern := range p.Denied {
+		if matchDomain(pattern, host) {
+			return fmt.Errorf("%w: %s is denied", ErrDomainNotAllowed, host)
+		}
+	}
+
+	if len(p.Allowed) == 0 {
+		return nil
+	}
+
+	for _, pattern := range p.Allowed {
+		if matchDomain(pattern, host) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s is not allowed", ErrDomainNotAllowed, host)
+}
+
+// matchDomain reports whether the host matches the domain pattern.
+func matchDomain(pattern string, host string) bool {
+	pattern = strings.TrimSuffix(strings.ToLower(pattern), ".")
+
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+
+	return host == pattern
+}
+
+// validateDomainPattern checks that the pattern is a domain,
+// optionally preceded by a wildcard label.
+func validateDomainPattern(pattern string) error {
+	domain := strings.TrimPrefix(pattern, "*.")
+
+	if domain == "" || strings.ContainsAny(domain, "*/:@ ") {
+		return fmt.Errorf("%w: %q", ErrInvalidDomainPattern, pattern)
+	}
+
+	return nil
+}
+
+// CheckDomain returns an error wrapping ErrDomainNotAllowed
+// when the domain policy does not allow rendering the host.
+//
+// It is also checked when taking a screenshot, callers can use it
+// to reject a request before waiting for a browser.
+func (mischief *Mischief) CheckDomain(host string) error {
+	return mischief.domainPolicy.Check(host)
+}
+
+// checkURL parses the URL of a page and checks it against the domain
+// and network policies before a browser is taken from the pool.
+func (mischief *Mischief) checkURL(ctx context.Context, rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSource, err)
+	}
+
+	if u.Scheme == "http" || u.Scheme == "https" {
+		err = mischief.domainPolicy.Check(u.Hostname())
+		if err != nil {
+			return err
+		}
+	}
+
+	return mischief.networkPolicy.Check(ctx, u)
+}
@@ -3,9 +3,38 @@ package mischief
 import "errors"
 
 var (
+	ErrNoExternalBrowser        = errors.New("no external browser URL provided")
 	ErrGettingBrowser           = errors.New("error getting browser from pool")
 	ErrGettingPage              = errors.New("error when getting page")
 	ErrNavigatingToPage         = errors.New("error when navigating to page")
+	ErrSettingDocumentContent   = errors.New("error when setting document content")
 	ErrWaitingForPageToBeStable = errors.New("error when waiting for page to be stable")
+	ErrWaitingForElement        = errors.New("error when waiting for element")
 	ErrWhileTakingScreenshot    = errors.New("error while taking screenshot")
+	ErrInvalidSource            = errors.New("invalid source")
+	ErrInvalidViewport          = errors.New("invalid viewport")
+	ErrInvalidClip              = errors.New("invalid clip")
+	ErrInvalidWait              = errors.New("invalid wait")
+	ErrSettingViewport          = errors.New("error when setting viewport")
+	ErrInvalidFormat            = errors.New("invalid format")
+	ErrInvalidQuality           = errors.New("invalid quality")
+	ErrWhileRenderingPDF        = errors.New("error while rendering pdf")
+	ErrInvalidPaperSize         = errors.New("invalid paper size")
+	ErrInvalidMargins           = errors.New("invalid margins")
+	ErrInvalidHeader            = errors.New("invalid header")
+	ErrInvalidCookie            = errors.New("invalid cookie")
+	ErrInvalidBasicAuth         = errors.New("invalid basic auth")
+	ErrApplyingSession          = errors.New("error when applying headers or cookies")
+	ErrBlockedByNetworkPolicy   = errors.New("blocked by network policy")
+	ErrInvalidNetwork           = errors.New("invalid network")
+	ErrInterceptingRequests     = errors.New("error when intercepting requests")
+	ErrDomainNotAllowed         = errors.New("domain not allowed")
+	ErrInvalidDomainPattern     = errors.New("invalid domain pattern")
+	ErrInvalidResourceBlocking  = errors.New("invalid resource blocking")
+	ErrInvalidHideSelector      = errors.New("invalid hide selector")
+	ErrHidingElements           = errors.New("error when hiding elements")
+	ErrInvalidScript            = errors.New("invalid script")
+	ErrEvaluatingScript         = errors.New("error when evaluating script")
+	ErrInvalidStep              = errors.New("invalid step")
+	ErrRunningSteps             = errors.New("error when running steps")
 )
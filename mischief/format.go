@@ -0,0 +1,92 @@
+package mischief
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Format is the image format of a screenshot.
+type Format string
+
+const (
+	FormatPNG  Format = "png"
+	FormatJPEG Format = "jpeg"
+	FormatWebP Format = "webp"
+)
+
+// Formats lists every supported screenshot format.
+var Formats = []Format{FormatPNG, FormatJPEG, FormatWebP}
+
+// ParseFormat parses a format name, such as "png", "jpg", "jpeg" or "webp".
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "png":
+		return FormatPNG, nil
+	case "jpg", "jpeg":
+		return FormatJPEG, nil
+	case "webp":
+		return FormatWebP, nil
+	}
+
+	return "", fmt.Errorf("%w: %q is not supported", ErrInvalidFormat, s)
+}
+
+// UnmarshalJSON parses the format name, normalizing aliases such as "jpg",
+// so that the options carry one of the supported formats.
+func (f *Format) UnmarshalJSON(data []byte) error {
+	var name string
+
+	err := json.Unmarshal(data, &name)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		*f = ""
+		return nil
+	}
+
+	*f, err = ParseFormat(name)
+	return err
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatWebP:
+		return "image/webp"
+	default:
+		return "image/png"
+	}
+}
+
+// Extension returns the file extension of the format, without dot.
+func (f Format) Extension() string {
+	if f == "" {
+		return string(FormatPNG)
+	}
+
+	return string(f)
+}
+
+// SupportsQuality reports whether the format is lossy and accepts a quality.
+func (f Format) SupportsQuality() bool {
+	return f == FormatJPEG || f == FormatWebP
+}
+
+// protoFormat returns the format understood by the DevTools protocol.
+func (f Format) protoFormat() proto.PageCaptureScreenshotFormat {
+	switch f {
+	case FormatJPEG:
+		return proto.PageCaptureScreenshotFormatJpeg
+	case FormatWebP:
+		return proto.PageCaptureScreenshotFormatWebp
+	default:
+		return proto.PageCaptureScreenshotFormatPng
+	}
+}
@@ -0,0 +1,71 @@
+package mischief
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// cookieBannersCSS hides the containers of common consent management platforms.
+//
+//go:embed cookie_banners.css
+var cookieBannersCSS string
+
+// cookieBannersJS clicks the accept buttons of common consent management platforms.
+//
+//go:embed cookie_banners.js
+var cookieBannersJS string
+
+// hidesElements reports whether the options hide elements of the page before capturing it.
+func (o ScreenshotOptions) hidesElements() bool {
+	return o.HideCookieBanners || len(o.HideSelectors) > 0 || o.CSS != ""
+}
+
+// validateHideSelectors checks that the selectors can be injected in a style sheet.
+func validateHideSelectors(selectors []string) error {
+	for _, selector := range selectors {
+		if strings.TrimSpace(selector) == "" || strings.ContainsAny(selector, "{}") {
+			return fmt.Errorf("%w: selector %q is invalid", ErrInvalidHideSelector, selector)
+		}
+	}
+
+	return nil
+}
+
+// hideElements dismisses the cookie banners, hides the elements matching
+// the selectors and injects the custom CSS of the options in a loaded page.
+func (mischief *Mischief) hideElements(page *rod.Page, options ScreenshotOptions) error {
+	timedPage := page.Timeout(mischief.pageStabilityTimeout)
+	defer timedPage.CancelTimeout()
+
+	var css strings.Builder
+
+	if options.HideCookieBanners {
+		clicked, err := timedPage.Eval(cookieBannersJS)
+		if err != nil {
+			return errors.Join(ErrHidingElements, err)
+		}
+
+		mischief.logger.Debug("mischief accepted cookie banners", slog.Any("clicked", clicked.Value.Int()))
+
+		css.WriteString(cookieBannersCSS)
+	}
+
+	// One rule per selector, so that an invalid selector does not void the others
+	for _, selector := range options.HideSelectors {
+		css.WriteString(selector + " { display: none !important; }\n")
+	}
+
+	css.WriteString(options.CSS)
+
+	err := timedPage.AddStyleTag("", css.String())
+	if err != nil {
+		return errors.Join(ErrHidingElements, err)
+	}
+
+	return nil
+}
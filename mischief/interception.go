@@ -0,0 +1,242 @@
+package mischief
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// interception intercepts the requests of a page to enforce the domain and
+// network policies, to block unwanted resources and to send the basic
+// authentication credentials to the origin.
+type interception struct {
+	logger  *slog.Logger
+	policy  NetworkPolicy
+	domains DomainPolicy
+	// blocker blocks the unwanted resources, nil when none is
+	blocker *resourceBlocker
+
+	// origin is the origin the credentials are sent to, nil without credentials
+	origin *url.URL
+	// authorization is the value of the Authorization header sent to the origin
+	authorization string
+
+	// checks caches the result of the policy per host, as hosts are resolved
+	checks sync.Map
+
+	mu sync.Mutex
+	// blocked is the error of the first document request blocked by a policy
+	blocked error
+	// violation is the error of the first response received from,
+	// or WebSocket opened to, an address blocked by the network policy
+	violation error
+
+	router *rod.HijackRouter
+}
+
+// interceptRequests starts intercepting the requests of the page when
+// the policies or the options require it, and watching the addresses the
+// responses are received from when the network policy can block some.
+//
+// The returned stop function must be called before the page goes back to
+// the pool. It runs detached from the context of the page so that it still
+// happens when the screenshot is cancelled.
+func (mischief *Mischief) interceptRequests(page *rod.Page, options ScreenshotOptions) (*interception, func(), error) {
+	i := &interception{
+		logger:  mischief.logger,
+		policy:  mischief.networkPolicy,
+		domains: mischief.domainPolicy,
+	}
+
+	blocking := mischief.resourceBlocking
+	if options.Blocking != nil {
+		blocking = *options.Blocking
+	}
+
+	i.blocker = newResourceBlocker(blocking)
+
+	if options.BasicAuth != nil {
+		origin, err := url.Parse(options.sessionOrigin())
+		if err != nil {
+			return nil, nil, errors.Join(ErrInterceptingRequests, err)
+		}
+
+		i.origin = origin
+		i.authorization = options.BasicAuth.header()
+	}
+
+	if i.policy.allowsEverything() && i.domains.IsZero() && i.blocker == nil && i.origin == nil {
+		return i, func() {}, nil
+	}
+
+	i.router = page.Context(context.Background()).HijackRequests()
+
+	err := i.router.Add("*", "", i.handle)
+	if err != nil {
+		_ = i.router.Stop()
+		return nil, nil, errors.Join(ErrInterceptingRequests, err)
+	}
+
+	go i.router.Run()
+
+	stopWatching := func() {}
+	if !i.policy.allowsEverything() {
+		stopWatching = i.watchRemoteAddresses(page)
+	}
+
+	return i, func() {
+		stopWatching()
+		_ = i.router.Stop()
+	}, nil
+}
+
+// watchRemoteAddresses checks the address of every response of the page,
+// and every WebSocket it opens, against the network policy until stopped.
+//
+// The browser resolves the hosts again after they were checked, this
+// catches the hosts resolving to a blocked address the second time.
+func (i *interception) watchRemoteAddresses(page *rod.Page) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	wait := page.Context(ctx).EachEvent(
+		func(e *proto.NetworkResponseReceived) {
+			if e.Response == nil {
+				return
+			}
+
+			responseURL, err := url.Parse(e.Response.URL)
+			if err != nil {
+				return
+			}
+
+			i.violate(responseURL, i.policy.checkRemoteAddress(responseURL.Hostname(), e.Response.RemoteIPAddress))
+		},
+		func(e *proto.NetworkWebSocketCreated) {
+			socketURL, err := url.Parse(e.URL)
+			if err != nil {
+				return
+			}
+
+			// The handshake is not intercepted, it can only be checked once started
+			i.violate(socketURL, i.check(ctx, socketURL))
+		},
+	)
+
+	go wait()
+
+	return cancel
+}
+
+// violate records the first violation of the network policy.
+func (i *interception) violate(u *url.URL, err error) {
+	if err == nil {
+		return
+	}
+
+	i.logger.Warn("mischief received a response from a blocked address", slog.Any("url", u.Redacted()), slog.Any("error", err))
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.violation == nil {
+		i.violation = err
+	}
+}
+
+// verify returns the violation of the network policy seen since the requests
+// started being intercepted, if any, in which case the capture must be discarded.
+func (i *interception) verify() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.violation
+}
+
+// handle fails the navigations to domains blocked by the domain policy,
+// the requests blocked by the network policy and the unwanted resources,
+// then continues the others, with the credentials when they go to the origin.
+func (i *interception) handle(ctx *rod.Hijack) {
+	requestURL := ctx.Request.URL()
+
+	var err error
+	if ctx.Request.IsNavigation() && (requestURL.Scheme == "http" || requestURL.Scheme == "https") {
+		err = i.domains.Check(requestURL.Hostname())
+	}
+
+	if err == nil {
+		err = i.check(ctx.Request.Req().Context(), requestURL)
+	}
+
+	if err != nil {
+		i.logger.Warn("mischief blocked a request", slog.Any("url", requestURL.Redacted()), slog.Any("error", err))
+
+		if ctx.Request.IsNavigation() {
+			i.mu.Lock()
+			if i.blocked == nil {
+				i.blocked = err
+			}
+			i.mu.Unlock()
+		}
+
+		ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+		return
+	}
+
+	if i.blocker != nil && i.blocker.blocks(ctx.Request.Type(), requestURL) {
+		ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+		return
+	}
+
+	if i.origin == nil || requestURL.Scheme != i.origin.Scheme || requestURL.Host != i.origin.Host {
+		ctx.ContinueRequest(&proto.FetchContinueRequest{})
+		return
+	}
+
+	var headers []*proto.FetchHeaderEntry
+	for name, value := range ctx.Request.Headers() {
+		if strings.EqualFold(name, "Authorization") {
+			continue
+		}
+
+		headers = append(headers, &proto.FetchHeaderEntry{Name: name, Value: value.String()})
+	}
+
+	headers = append(headers, &proto.FetchHeaderEntry{Name: "Authorization", Value: i.authorization})
+	ctx.ContinueRequest(&proto.FetchContinueRequest{Headers: headers})
+}
+
+// check checks the URL against the network policy, once per host.
+func (i *interception) check(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return i.policy.Check(ctx, u)
+	}
+
+	if cached, ok := i.checks.Load(u.Host); ok {
+		err, _ := cached.(error)
+		return err
+	}
+
+	err := i.policy.Check(ctx, u)
+	i.checks.Store(u.Host, err)
+
+	return err
+}
+
+// explain returns the error of a failed page load,
+// blamed on a policy when it blocked a document.
+func (i *interception) explain(err error) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.blocked != nil {
+		return errors.Join(i.blocked, err)
+	}
+
+	return err
+}
@@ -0,0 +1,81 @@
+package mischief
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yyewolf/rodent/metrics"
+)
+
+// sentinels names the sentinel errors counted by the metrics.
+//
+// Errors often wrap several sentinels, such as a navigation blocked by
+// the network policy, the ones explaining the failure come first.
+var sentinels = []struct {
+	name string
+	err  error
+}{
+	{"ErrBlockedByNetworkPolicy", ErrBlockedByNetworkPolicy},
+	{"ErrDomainNotAllowed", ErrDomainNotAllowed},
+	{"ErrInvalidSource", ErrInvalidSource},
+	{"ErrInvalidViewport", ErrInvalidViewport},
+	{"ErrInvalidClip", ErrInvalidClip},
+	{"ErrInvalidWait", ErrInvalidWait},
+	{"ErrInvalidFormat", ErrInvalidFormat},
+	{"ErrInvalidQuality", ErrInvalidQuality},
+	{"ErrInvalidPaperSize", ErrInvalidPaperSize},
+	{"ErrInvalidMargins", ErrInvalidMargins},
+	{"ErrInvalidHeader", ErrInvalidHeader},
+	{"ErrInvalidCookie", ErrInvalidCookie},
+	{"ErrInvalidBasicAuth", ErrInvalidBasicAuth},
+	{"ErrInvalidNetwork", ErrInvalidNetwork},
+	{"ErrInvalidDomainPattern", ErrInvalidDomainPattern},
+	{"ErrInvalidResourceBlocking", ErrInvalidResourceBlocking},
+	{"ErrInvalidHideSelector", ErrInvalidHideSelector},
+	{"ErrInvalidScript", ErrInvalidScript},
+	{"ErrInvalidStep", ErrInvalidStep},
+	{"ErrGettingBrowser", ErrGettingBrowser},
+	{"ErrGettingPage", ErrGettingPage},
+	{"ErrInterceptingRequests", ErrInterceptingRequests},
+	{"ErrApplyingSession", ErrApplyingSession},
+	{"ErrSettingViewport", ErrSettingViewport},
+	{"ErrNavigatingToPage", ErrNavigatingToPage},
+	{"ErrSettingDocumentContent", ErrSettingDocumentContent},
+	{"ErrWaitingForPageToBeStable", ErrWaitingForPageToBeStable},
+	{"ErrWaitingForElement", ErrWaitingForElement},
+	{"ErrRunningSteps", ErrRunningSteps},
+	{"ErrEvaluatingScript", ErrEvaluatingScript},
+	{"ErrHidingElements", ErrHidingElements},
+	{"ErrWhileTakingScreenshot", ErrWhileTakingScreenshot},
+	{"ErrWhileRenderingPDF", ErrWhileRenderingPDF},
+}
+
+// sentinelName returns the name of the sentinel error wrapped by the error,
+// or of its context error, "Unknown" when there is none.
+func sentinelName(err error) string {
+	for _, sentinel := range sentinels {
+		if errors.Is(err, sentinel.err) {
+			return sentinel.name
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "Canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "DeadlineExceeded"
+	}
+
+	return "Unknown"
+}
+
+// recordError counts the error returned by a screenshot or a PDF, if any.
+//
+// Example:
+//
+//	defer recordError(&err)
+func recordError(err *error) {
+	if *err != nil {
+		metrics.Errors.WithLabelValues(sentinelName(*err)).Inc()
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/go-rod/rod"
+	"github.com/yyewolf/rodent/metrics"
 	"github.com/yyewolf/rodent/rat"
 )
 
@@ -22,6 +23,16 @@ type Mischief struct {
 	// pageConcurrency is the number of pages to use to take screenshots concurrently
 	pageConcurrency int
 
+	// isolatedPages runs every screenshot in its own incognito browser context
+	isolatedPages bool
+
+	// networkPolicy decides which hosts the pages are allowed to reach
+	networkPolicy NetworkPolicy
+	// domainPolicy restricts the sites rendered by their domain names
+	domainPolicy DomainPolicy
+	// resourceBlocking are the requests blocked when a screenshot does not specify them
+	resourceBlocking ResourceBlocking
+
 	// logger is the logger of the Mischief instance
 	logger *slog.Logger
 
@@ -36,6 +47,9 @@ type Mischief struct {
 	// pageStabilityTimeout is the timeout used when waiting for the page to be stable
 	pageStabilityTimeout time.Duration
 
+	// fullPageMaxHeight is the maximum height captured by a full page screenshot
+	fullPageMaxHeight int
+
 	// watchratCancel is the context used to watch the rat
 	watchratCancel context.CancelFunc
 }
@@ -52,6 +66,9 @@ type MischiefOpt func(*Mischief)
 //		mischief.WithLogger(slog.Default()),
 //		mischief.WithBrowserRetakeTimeout(5*time.Second),
 //		mischief.WithPageStabilityTimeout(3*time.Second),
+//		mischief.WithFullPageMaxHeight(16384),
+//		mischief.WithIsolatedPages(true),
+//		mischief.WithNetworkPolicy(mischief.NetworkPolicy{}),
 //	)
 func New(opts ...MischiefOpt) (*Mischief, error) {
 	var m Mischief
@@ -63,6 +80,9 @@ func New(opts ...MischiefOpt) (*Mischief, error) {
 		WithBrowserRetakeTimeout(5 * time.Second),
 		WithPageRetakeTimeout(5 * time.Second),
 		WithPageStabilityTimeout(3 * time.Second),
+		WithFullPageMaxHeight(16384),
+		WithIsolatedPages(true),
+		WithNetworkPolicy(NetworkPolicy{}),
 	}
 
 	opts = append(defaultOpts, opts...)
@@ -88,25 +108,48 @@ func New(opts ...MischiefOpt) (*Mischief, error) {
 //
 // It creates a pool of browsers to take screenshots concurrently.
 func (mischief *Mischief) initialize() error {
+	if mischief.externalBrowser && len(mischief.browserUrls) == 0 {
+		return ErrNoExternalBrowser
+	}
+
+	err := mischief.domainPolicy.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = mischief.resourceBlocking.Validate()
+	if err != nil {
+		return err
+	}
+
 	mischief.ratPool = rod.NewPool[rat.Rat](mischief.browserConcurrency * mischief.pageConcurrency)
+	metrics.RatPoolSize.Add(float64(mischief.browserConcurrency * mischief.pageConcurrency))
 
 	var rats []*rat.Rat = make([]*rat.Rat, mischief.browserConcurrency)
 
 	// Instanciate every browser
 	for i := 0; i < mischief.browserConcurrency; i++ {
-		var err error
+		ratOpts := []rat.RatOpt{
+			rat.WithPagePoolLength(mischief.pageConcurrency),
+			rat.WithPageRetakeTimeout(mischief.pageRetakeTimeout),
+			rat.WithCreateBrowserFunc(launchBrowser),
+			rat.WithIsolatedPages(mischief.isolatedPages),
+		}
+
 		var uri *string
 
 		if mischief.externalBrowser {
 			uri = &mischief.browserUrls[i]
+
+			connect, disconnect := connectBrowser(*uri)
+			ratOpts = append(ratOpts,
+				rat.WithCreateBrowserFunc(connect),
+				rat.WithCloseBrowserFunc(disconnect),
+			)
 		}
 
 		mischief.logger.Info("mischief is creating rat", slog.Any("uri", uri))
-		rat, err := rat.New(
-			rat.WithPagePoolLength(mischief.pageConcurrency),
-			rat.WithPageRetakeTimeout(mischief.pageRetakeTimeout),
-			rat.WithCreateBrowserFunc(createBrowser(uri)),
-		)
+		rat, err := rat.New(ratOpts...)
 		if err != nil {
 			return err
 		}
@@ -50,10 +50,11 @@ func WithBrowserConcurrency(c int) MischiefOpt {
 // WithPageConcurrency is an option to set the concurrency of the
 // screenshotting process.
 //
-// By default, this will create a pool of <n> pages to
-// take screenshots concurrently.
+// By default, this will create a pool of <n> pages per browser to
+// take screenshots concurrently, the total concurrency being
+// the browser concurrency multiplied by the page concurrency.
 //
-// A page will be used only once to take a screenshot.
+// A page is used by a single screenshot at a time.
 func WithPageConcurrency(c int) MischiefOpt {
 	return func(m *Mischief) {
 		m.pageConcurrency = c
@@ -122,3 +123,119 @@ func WithPageStabilityTimeout(timeout time.Duration) MischiefOpt {
 		m.pageStabilityTimeout = timeout
 	}
 }
+
+// WithFullPageMaxHeight is an option to set the maximum height
+// captured by a full page screenshot.
+//
+// Longer documents are truncated to this height to protect the memory
+// of the browsers.
+//
+// By default, this is set to 16384 pixels.
+//
+// Example:
+//
+//	m := mischief.New(
+//		mischief.WithFullPageMaxHeight(8192),
+//	)
+func WithFullPageMaxHeight(height int) MischiefOpt {
+	return func(m *Mischief) {
+		m.fullPageMaxHeight = height
+	}
+}
+
+// WithIsolatedPages is an option to run every screenshot in its own
+// incognito browser context, so that cookies, storage and cache of a
+// screenshot never appear in another one.
+//
+// When disabled, pages are reused across screenshots, which is faster
+// but shares the state of the browser between them.
+//
+// By default, this is set to true.
+//
+// Example:
+//
+//	m := mischief.New(
+//		mischief.WithIsolatedPages(false),
+//	)
+func WithIsolatedPages(isolated bool) MischiefOpt {
+	return func(m *Mischief) {
+		m.isolatedPages = isolated
+	}
+}
+
+// WithNetworkPolicy is an option to set the policy deciding which hosts
+// the pages are allowed to reach.
+//
+// The policy is checked before loading a page, and on every request
+// of the page, including redirects and subresources.
+//
+// By default, pages cannot reach private, loopback, link-local, multicast
+// and reserved addresses.
+//
+// Example:
+//
+//	m := mischief.New(
+//		mischief.WithNetworkPolicy(mischief.NetworkPolicy{
+//			AllowedHosts: []string{"grafana.internal"},
+//			DeniedNetworks: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")},
+//		}),
+//	)
+func WithNetworkPolicy(policy NetworkPolicy) MischiefOpt {
+	return func(m *Mischief) {
+		m.networkPolicy = policy
+	}
+}
+
+// WithAllowedDomains is an option to only render the sites whose
+// domain matches one of the patterns, such as "*.example.com".
+//
+// The domain is checked before loading a page, and on every navigation
+// of the page, including redirects and frames.
+//
+// By default, every domain is allowed.
+//
+// Example:
+//
+//	m := mischief.New(
+//		mischief.WithAllowedDomains([]string{"example.com", "*.example.com"}),
+//	)
+func WithAllowedDomains(patterns []string) MischiefOpt {
+	return func(m *Mischief) {
+		m.domainPolicy.Allowed = patterns
+	}
+}
+
+// WithDeniedDomains is an option to never render the sites whose
+// domain matches one of the patterns, such as "*.example.com".
+//
+// Denied domains take precedence over allowed ones.
+//
+// Example:
+//
+//	m := mischief.New(
+//		mischief.WithDeniedDomains([]string{"*.internal.example.com"}),
+//	)
+func WithDeniedDomains(patterns []string) MischiefOpt {
+	return func(m *Mischief) {
+		m.domainPolicy.Denied = patterns
+	}
+}
+
+// WithResourceBlocking is an option to set the requests blocked
+// while loading a page, when the screenshot does not specify them.
+//
+// By default, nothing is blocked.
+//
+// Example:
+//
+//	m := mischief.New(
+//		mischief.WithResourceBlocking(mischief.ResourceBlocking{
+//			Ads:           true,
+//			ResourceTypes: []mischief.ResourceType{mischief.ResourceMedia},
+//		}),
+//	)
+func WithResourceBlocking(blocking ResourceBlocking) MischiefOpt {
+	return func(m *Mischief) {
+		m.resourceBlocking = blocking
+	}
+}
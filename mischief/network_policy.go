@@ -0,0 +1,223 @@
+package mischief
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// blockedNetworks are the ranges, besides the private, loopback, link-local
+// and multicast ones, that pages cannot reach by default.
+var blockedNetworks = []netip.Prefix{
+	// "This" network
+	netip.MustParsePrefix("0.0.0.0/8"),
+	// Shared address space, used by some cloud metadata services
+	netip.MustParsePrefix("100.64.0.0/10"),
+	// IETF protocol assignments
+	netip.MustParsePrefix("192.0.0.0/24"),
+	// Benchmarking
+	netip.MustParsePrefix("198.18.0.0/15"),
+	// Reserved and broadcast
+	netip.MustParsePrefix("240.0.0.0/4"),
+	// NAT64, which can translate to any of the above
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// NetworkPolicy decides which hosts the pages are allowed to reach.
+//
+// By default, pages cannot reach private, loopback, link-local (including
+// cloud metadata services), multicast and reserved addresses, so that
+// rendering a page cannot be used to reach the internal network.
+//
+// Hostnames are resolved before deciding, the policy is therefore enforced
+// on the addresses they resolve to and not on their names only. The browser
+// resolves them again on its own, so the address every response was received
+// from is checked as well, and the capture fails when one is blocked: a host
+// answering with a public address to the policy, then with a blocked one to
+// the browser (DNS rebinding), cannot end up in a screenshot.
+//
+// The policy does not cover:
+//
+// - The connections themselves: a request to a rebound host still reaches
+// the blocked address, only its response is kept out of the capture.
+// Requests with side effects on the internal network must be prevented by
+// an egress firewall.
+//
+// - WebSockets, which are not intercepted: the capture fails when the page
+// opens one to a blocked host, after the handshake happened.
+//
+// - Out-of-process iframes, whose requests are not seen by the page. Local
+// browsers are launched without site isolation so that frames stay in the
+// process of their page, external browsers must be started with
+// --disable-site-isolation-trials --disable-features=site-per-process,IsolateOrigins.
+type NetworkPolicy struct {
+	// AllowPrivateNetworks lets pages reach the ranges blocked by default
+	AllowPrivateNetworks bool
+	// AllowedHosts are hosts reachable whatever they resolve to
+	AllowedHosts []string
+	// DeniedHosts are hosts never reachable
+	DeniedHosts []string
+	// AllowedNetworks are ranges reachable even though they are blocked by default
+	AllowedNetworks []netip.Prefix
+	// DeniedNetworks are ranges never reachable
+	DeniedNetworks []netip.Prefix
+}
+
+// allowsEverything reports whether the policy never blocks a request,
+// in which case requests do not need to be intercepted.
+func (p NetworkPolicy) allowsEverything() bool {
+	return p.AllowPrivateNetworks && len(p.DeniedHosts) == 0 && len(p.DeniedNetworks) == 0
+}
+
+// Check returns an error wrapping ErrBlockedByNetworkPolicy
+// when a page cannot reach the URL.
+func (p NetworkPolicy) Check(ctx context.Context, u *url.URL) error {
+	switch u.Scheme {
+	case "http", "https", "ws", "wss":
+	case "data", "blob", "about":
+		// These do not reach the network
+		return nil
+	default:
+		return fmt.Errorf("%w: scheme %q is not allowed", ErrBlockedByNetworkPolicy, u.Scheme)
+	}
+
+	return p.checkHost(ctx, u.Hostname())
+}
+
+// checkHost checks the host against the lists of the policy,
+// then the addresses it resolves to.
+func (p NetworkPolicy) checkHost(ctx context.Context, host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	if containsHost(p.DeniedHosts, host) {
+		return fmt.Errorf("%w: host %s is denied", ErrBlockedByNetworkPolicy, host)
+	}
+
+	if containsHost(p.AllowedHosts, host) {
+		return nil
+	}
+
+	addrs, err := resolveHost(ctx, host)
+	if err != nil {
+		// Fail closed, the browser may resolve the host differently
+		return fmt.Errorf("%w: failed to resolve host %s: %w", ErrBlockedByNetworkPolicy, host, err)
+	}
+
+	for _, addr := range addrs {
+		if !p.allowsAddress(addr) {
+			return fmt.Errorf("%w: host %s resolves to %s", ErrBlockedByNetworkPolicy, host, addr)
+		}
+	}
+
+	return nil
+}
+
+// checkRemoteAddress returns an error wrapping ErrBlockedByNetworkPolicy
+// when a response of the host was received from a blocked address.
+//
+// Responses without an address, such as cached ones, are not checked.
+func (p NetworkPolicy) checkRemoteAddress(host string, remoteAddress string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	if remoteAddress == "" || containsHost(p.AllowedHosts, host) {
+		return nil
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(remoteAddress, "[]"))
+	if err != nil {
+		return fmt.Errorf("%w: host %s answered from invalid address %q", ErrBlockedByNetworkPolicy, host, remoteAddress)
+	}
+
+	if !p.allowsAddress(addr) {
+		return fmt.Errorf("%w: host %s answered from %s", ErrBlockedByNetworkPolicy, host, addr)
+	}
+
+	return nil
+}
+
+// allowsAddress checks the address against the networks of the policy.
+func (p NetworkPolicy) allowsAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if containsAddress(p.DeniedNetworks, addr) {
+		return false
+	}
+
+	if containsAddress(p.AllowedNetworks, addr) {
+		return true
+	}
+
+	return p.AllowPrivateNetworks || !isBlockedAddress(addr)
+}
+
+// resolveHost returns the addresses of the host, the host itself when it is an IP address.
+func resolveHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	if err == nil {
+		return []netip.Addr{addr}, nil
+	}
+
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+// isBlockedAddress reports whether the address is blocked by default.
+func isBlockedAddress(addr netip.Addr) bool {
+	return addr.IsPrivate() ||
+		addr.IsLoopback() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() ||
+		containsAddress(blockedNetworks, addr)
+}
+
+// containsHost reports whether the host is one of the hosts, ignoring case.
+func containsHost(hosts []string, host string) bool {
+	return slices.ContainsFunc(hosts, func(h string) bool {
+		return strings.EqualFold(strings.TrimSuffix(h, "."), host)
+	})
+}
+
+// containsAddress reports whether one of the networks contains the address.
+func containsAddress(networks []netip.Prefix, addr netip.Addr) bool {
+	for _, network := range networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseNetworks parses a list of CIDRs, a single IP address being
+// parsed as the network containing only this address.
+func ParseNetworks(values []string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidNetwork, value)
+			}
+
+			networks = append(networks, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		network, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidNetwork, value)
+		}
+
+		networks = append(networks, network.Masked())
+	}
+
+	return networks, nil
+}
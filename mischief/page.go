@@ -0,0 +1,341 @@
+package mischief
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"math"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/devices"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/yyewolf/rodent/metrics"
+	"github.com/yyewolf/rodent/rat"
+)
+
+// defaultDevice is the device rod emulates on every new page,
+// pages are reset to it before going back to the pool.
+var defaultDevice = devices.LaptopWithMDPIScreen.Landscape()
+
+// withPage gets a browser from the pool and a page from the browser,
+// then runs fn on the page bound to the context.
+//
+// Pages of a same browser are used concurrently, the read lock of the rat
+// only prevents the browser from being closed or recreated meanwhile.
+//
+// It resets the page and puts the browser back to the pool after returning,
+// even when the context is cancelled.
+func (mischief *Mischief) withPage(ctx context.Context, fn func(page *rod.Page) error) error {
+	start := time.Now()
+	r, err := mischief.getRat(ctx)
+	metrics.ObservePhase(metrics.PhaseGetRat, start)
+	if err != nil {
+		return errors.Join(ErrGettingBrowser, err)
+	}
+	defer mischief.ratPool.Put(r)
+
+	metrics.RatPoolInUse.Inc()
+	defer metrics.RatPoolInUse.Dec()
+
+	r.RLock()
+	defer r.RUnlock()
+
+	start = time.Now()
+	page, err := r.GetPage(ctx)
+	metrics.ObservePhase(metrics.PhaseGetPage, start)
+	if errors.Is(err, rat.ErrCreatingPage) {
+		// The connection to the browser is probably lost, reconnect once
+		mischief.logger.Warn("mischief failed to create page, recreating rat", slog.Any("error", err))
+
+		stale := r.Browser
+		r.RUnlock()
+		err = r.Reconnect(stale)
+		r.RLock()
+
+		if err == nil {
+			metrics.BrowserRecreations.WithLabelValues("reconnect").Inc()
+			page, err = r.GetPage(ctx)
+		}
+	}
+	if err != nil {
+		return errors.Join(ErrGettingPage, err)
+	}
+
+	// Pooled pages share the cookies of the browser, the ones of the visited URLs are cleared on release
+	visited := &visits{}
+	if !mischief.isolatedPages {
+		var stopTracking func()
+		visited, stopTracking = trackVisits(page)
+		defer stopTracking()
+	}
+	defer mischief.releasePage(r, page, visited)
+
+	return fn(page.Context(ctx))
+}
+
+// pageSource loads the content to render in a page.
+type pageSource func(page *rod.Page) error
+
+// navigateTo is the page source navigating to the given URL.
+func navigateTo(url string) pageSource {
+	return func(page *rod.Page) error {
+		err := page.Navigate(url)
+		if err != nil {
+			return errors.Join(ErrNavigatingToPage, err)
+		}
+
+		return nil
+	}
+}
+
+// documentContent is the page source rendering the given HTML document.
+//
+// The page is first navigated to a blank page so that the document
+// does not inherit the origin of a previous screenshot.
+func documentContent(html string) pageSource {
+	return func(page *rod.Page) error {
+		err := page.Navigate("about:blank")
+		if err != nil {
+			return errors.Join(ErrSettingDocumentContent, err)
+		}
+
+		err = page.SetDocumentContent(html)
+		if err != nil {
+			return errors.Join(ErrSettingDocumentContent, err)
+		}
+
+		return nil
+	}
+}
+
+// loadPage applies the viewport to the page, loads its content
+// and runs the waits, the DOM stable wait when none is given.
+func (mischief *Mischief) loadPage(page *rod.Page, source pageSource, viewport Viewport, waits []Wait) error {
+	err := applyViewport(page, viewport)
+	if err != nil {
+		return errors.Join(ErrSettingViewport, err)
+	}
+
+	// Tracked before loading, so that the requests sent while it loads are waited for
+	idles, stopTracking := trackRequests(page, waits)
+	defer stopTracking()
+
+	start := time.Now()
+	timedPage := page.Timeout(mischief.pageStabilityTimeout)
+	err = source(timedPage)
+	timedPage.CancelTimeout()
+	metrics.ObservePhase(metrics.PhaseNavigate, start)
+
+	if err != nil {
+		return err
+	}
+
+	defer metrics.ObservePhase(metrics.PhaseWait, time.Now())
+
+	return mischief.waitFor(page, waits, idles)
+}
+
+// applyViewport emulates the given viewport on the page.
+//
+// Dimensions left to zero fall back to the default device ones.
+func applyViewport(page *rod.Page, viewport Viewport) error {
+	if viewport.IsZero() {
+		return nil
+	}
+
+	emulation := defaultDevice.MetricsEmulation()
+
+	if viewport.Width > 0 {
+		emulation.Width = viewport.Width
+	}
+
+	if viewport.Height > 0 {
+		emulation.Height = viewport.Height
+	}
+
+	if viewport.DeviceScaleFactor > 0 {
+		emulation.DeviceScaleFactor = viewport.DeviceScaleFactor
+	}
+
+	emulation.Mobile = viewport.Mobile
+
+	if emulation.Height > emulation.Width {
+		emulation.ScreenOrientation = &proto.EmulationScreenOrientation{
+			Angle: 0,
+			Type:  proto.EmulationScreenOrientationTypePortraitPrimary,
+		}
+	}
+
+	err := page.SetViewport(emulation)
+	if err != nil {
+		return err
+	}
+
+	touch := proto.EmulationSetTouchEmulationEnabled{
+		Enabled: viewport.Touch,
+	}
+
+	if viewport.Touch {
+		maxTouchPoints := 5
+		touch.MaxTouchPoints = &maxTouchPoints
+	}
+
+	return touch.Call(page)
+}
+
+// fullPageClip computes the clip covering the whole scrollable document,
+// truncated to maxHeight.
+func fullPageClip(page *rod.Page, maxHeight int) (*proto.PageViewport, error) {
+	layout, err := proto.PageGetLayoutMetrics{}.Call(page)
+	if err != nil {
+		return nil, err
+	}
+
+	if layout.CSSContentSize == nil || layout.CSSLayoutViewport == nil {
+		return nil, errors.New("failed to get page layout metrics")
+	}
+
+	height := math.Min(layout.CSSContentSize.Height, float64(maxHeight))
+
+	return &proto.PageViewport{
+		X:      0,
+		Y:      0,
+		Width:  float64(layout.CSSLayoutViewport.ClientWidth),
+		Height: height,
+		Scale:  1,
+	}, nil
+}
+
+// elementClip waits for the first element matching the selector,
+// scrolls it into view and computes the clip covering its bounding box.
+func elementClip(page *rod.Page, selector string) (*proto.PageViewport, error) {
+	element, err := page.Element(selector)
+	if err != nil {
+		return nil, errors.Join(ErrWaitingForElement, err)
+	}
+
+	err = element.ScrollIntoView()
+	if err != nil {
+		return nil, err
+	}
+
+	shape, err := element.Shape()
+	if err != nil {
+		return nil, err
+	}
+
+	box := shape.Box()
+	if box == nil {
+		return nil, errors.Join(ErrWaitingForElement, errors.New("element is not visible"))
+	}
+
+	layout, err := proto.PageGetLayoutMetrics{}.Call(page)
+	if err != nil {
+		return nil, err
+	}
+
+	if layout.CSSLayoutViewport == nil {
+		return nil, errors.New("failed to get page layout metrics")
+	}
+
+	// The box is relative to the viewport while the clip is relative to the document
+	return &proto.PageViewport{
+		X:      box.X + float64(layout.CSSLayoutViewport.PageX),
+		Y:      box.Y + float64(layout.CSSLayoutViewport.PageY),
+		Width:  box.Width,
+		Height: box.Height,
+		Scale:  1,
+	}, nil
+}
+
+// visits records the URLs of the requests sent by a pooled page,
+// so that the cookies they set can be cleared when it goes back to the pool.
+type visits struct {
+	mu   sync.Mutex
+	urls map[string]struct{}
+}
+
+// trackVisits records the URLs of the requests of the page until stopped,
+// the redirects included. It runs detached from the context of the page
+// so that it still happens when the screenshot is cancelled.
+func trackVisits(page *rod.Page) (*visits, func()) {
+	v := &visits{urls: map[string]struct{}{}}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	wait := page.Context(ctx).EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+
+		// Cookies are scoped by host and path, the query does not matter
+		u.RawQuery, u.Fragment = "", ""
+
+		v.mu.Lock()
+		v.urls[u.String()] = struct{}{}
+		v.mu.Unlock()
+	})
+
+	go wait()
+
+	return v, cancel
+}
+
+// list returns the recorded URLs.
+func (v *visits) list() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return slices.Collect(maps.Keys(v.urls))
+}
+
+// resetPage restores the page to the state it had when it was created
+// so that it can safely be reused by another screenshot.
+//
+// The cookies that would be sent to the visited URLs are deleted, as they
+// are shared by all the pages of the browser and would otherwise be sent by
+// the next screenshots. The cookie jar is not cleared as a whole, other pages
+// of the browser are rendered concurrently.
+func resetPage(page *rod.Page, visited []string) error {
+	if len(visited) > 0 {
+		cookies, err := proto.NetworkGetCookies{Urls: visited}.Call(page)
+		if err != nil {
+			return err
+		}
+
+		for _, cookie := range cookies.Cookies {
+			err = proto.NetworkDeleteCookies{
+				Name:   cookie.Name,
+				Domain: cookie.Domain,
+				Path:   cookie.Path,
+			}.Call(page)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return page.Emulate(defaultDevice)
+}
+
+// releasePage resets the page and puts it back in the page pool of the rat.
+//
+// Isolated pages are not reset as they are closed with their browser context.
+func (mischief *Mischief) releasePage(rat *rat.Rat, page *rod.Page, visited *visits) {
+	if !mischief.isolatedPages {
+		err := resetPage(page, visited.list())
+		if err != nil {
+			mischief.logger.Warn("mischief failed to reset page", slog.Any("error", err))
+		}
+	}
+
+	err := rat.PutPage(page)
+	if err != nil {
+		mischief.logger.Warn("mischief failed to close isolated page", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,115 @@
+package mischief
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/yyewolf/rodent/metrics"
+)
+
+// RenderPDF prints the given URL as a PDF document.
+// It returns the document as a byte slice.
+//
+// It uses the same browser pool and network policy as TakeScreenshot.
+// When the context is cancelled, the rendering is aborted and the browser released.
+func (mischief *Mischief) RenderPDF(ctx context.Context, url string, opts ...PDFOpt) (_ []byte, err error) {
+	defer recordError(&err)
+
+	var options PDFOptions
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	err = options.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = mischief.checkURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	mischief.logger.Info("mischief is rendering a pdf", slog.Any("url", url))
+
+	var bytes []byte
+
+	err = mischief.withPage(ctx, func(page *rod.Page) error {
+		interception, stop, err := mischief.interceptRequests(page, ScreenshotOptions{})
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		err = mischief.loadPage(page, navigateTo(url), Viewport{}, nil)
+		if err != nil {
+			return interception.explain(err)
+		}
+
+		bytes, err = printPDF(page, options)
+		if err != nil {
+			return err
+		}
+
+		return interception.verify()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes, nil
+}
+
+// printPDF prints a loaded page as a PDF document.
+func printPDF(page *rod.Page, options PDFOptions) ([]byte, error) {
+	defer metrics.ObservePhase(metrics.PhaseCapture, time.Now())
+
+	pdfParams := &proto.PagePrintToPDF{
+		Landscape:           options.Landscape,
+		PrintBackground:     options.PrintBackground,
+		DisplayHeaderFooter: options.HeaderTemplate != "" || options.FooterTemplate != "",
+		HeaderTemplate:      options.HeaderTemplate,
+		FooterTemplate:      options.FooterTemplate,
+	}
+
+	// An empty template makes the browser print its default one
+	if pdfParams.DisplayHeaderFooter {
+		if pdfParams.HeaderTemplate == "" {
+			pdfParams.HeaderTemplate = "<span></span>"
+		}
+
+		if pdfParams.FooterTemplate == "" {
+			pdfParams.FooterTemplate = "<span></span>"
+		}
+	}
+
+	if options.PaperSize.Width > 0 {
+		pdfParams.PaperWidth = &options.PaperSize.Width
+		pdfParams.PaperHeight = &options.PaperSize.Height
+	}
+
+	if options.Margins != nil {
+		pdfParams.MarginTop = &options.Margins.Top
+		pdfParams.MarginRight = &options.Margins.Right
+		pdfParams.MarginBottom = &options.Margins.Bottom
+		pdfParams.MarginLeft = &options.Margins.Left
+	}
+
+	reader, err := page.PDF(pdfParams)
+	if err != nil {
+		return nil, errors.Join(ErrWhileRenderingPDF, err)
+	}
+
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Join(ErrWhileRenderingPDF, err)
+	}
+
+	return bytes, nil
+}
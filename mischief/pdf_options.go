@@ -0,0 +1,161 @@
+package mischief
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PaperSize is the size of a PDF page in inches.
+type PaperSize struct {
+	// Width is the width of the paper in inches
+	Width float64
+	// Height is the height of the paper in inches
+	Height float64
+}
+
+// PaperSizes lists the named paper sizes.
+var PaperSizes = map[string]PaperSize{
+	"letter":  {Width: 8.5, Height: 11},
+	"legal":   {Width: 8.5, Height: 14},
+	"tabloid": {Width: 11, Height: 17},
+	"ledger":  {Width: 17, Height: 11},
+	"a0":      {Width: 33.1, Height: 46.8},
+	"a1":      {Width: 23.4, Height: 33.1},
+	"a2":      {Width: 16.54, Height: 23.4},
+	"a3":      {Width: 11.7, Height: 16.54},
+	"a4":      {Width: 8.27, Height: 11.7},
+	"a5":      {Width: 5.83, Height: 8.27},
+	"a6":      {Width: 4.13, Height: 5.83},
+}
+
+// ParsePaperSize returns the paper size of the given name, such as "a4" or "letter".
+func ParsePaperSize(name string) (PaperSize, error) {
+	size, ok := PaperSizes[strings.ToLower(name)]
+	if !ok {
+		return PaperSize{}, fmt.Errorf("%w: unknown paper size %q", ErrInvalidPaperSize, name)
+	}
+
+	return size, nil
+}
+
+// Margins are the margins of a PDF page in inches.
+type Margins struct {
+	Top    float64
+	Right  float64
+	Bottom float64
+	Left   float64
+}
+
+// PDFOptions holds the options used when rendering a PDF.
+type PDFOptions struct {
+	// PaperSize is the size of the pages, letter when zero
+	PaperSize PaperSize
+	// Margins are the margins of the pages, the browser default (0.4 inches) when nil
+	Margins *Margins
+	// Landscape prints the pages in landscape orientation
+	Landscape bool
+	// PrintBackground prints the background graphics
+	PrintBackground bool
+	// HeaderTemplate is the HTML template of the header of every page
+	HeaderTemplate string
+	// FooterTemplate is the HTML template of the footer of every page
+	FooterTemplate string
+}
+
+// Validate checks that the PDF options are consistent.
+func (o PDFOptions) Validate() error {
+	if o.PaperSize.Width < 0 || o.PaperSize.Height < 0 {
+		return fmt.Errorf("%w: dimensions must be positive", ErrInvalidPaperSize)
+	}
+
+	if (o.PaperSize.Width == 0) != (o.PaperSize.Height == 0) {
+		return fmt.Errorf("%w: both width and height must be set", ErrInvalidPaperSize)
+	}
+
+	if o.Margins != nil {
+		if o.Margins.Top < 0 || o.Margins.Right < 0 || o.Margins.Bottom < 0 || o.Margins.Left < 0 {
+			return fmt.Errorf("%w: margins must be positive", ErrInvalidMargins)
+		}
+	}
+
+	return nil
+}
+
+type PDFOpt func(*PDFOptions)
+
+// WithPaperSize is an option to set the size of the pages of the PDF.
+//
+// By default, pages are printed on letter paper.
+//
+// Example:
+//
+//	bytes, err := m.RenderPDF(ctx, url,
+//		mischief.WithPaperSize(mischief.PaperSizes["a4"]),
+//	)
+func WithPaperSize(size PaperSize) PDFOpt {
+	return func(o *PDFOptions) {
+		o.PaperSize = size
+	}
+}
+
+// WithMargins is an option to set the margins of the pages of the PDF, in inches.
+//
+// By default, the browser default margins (0.4 inches) are used.
+//
+// Example:
+//
+//	bytes, err := m.RenderPDF(ctx, url,
+//		mischief.WithMargins(mischief.Margins{Top: 1, Right: 1, Bottom: 1, Left: 1}),
+//	)
+func WithMargins(margins Margins) PDFOpt {
+	return func(o *PDFOptions) {
+		o.Margins = &margins
+	}
+}
+
+// WithLandscape is an option to print the pages of the PDF in landscape orientation.
+//
+// Example:
+//
+//	bytes, err := m.RenderPDF(ctx, url,
+//		mischief.WithLandscape(),
+//	)
+func WithLandscape() PDFOpt {
+	return func(o *PDFOptions) {
+		o.Landscape = true
+	}
+}
+
+// WithPrintBackground is an option to print the background graphics of the page.
+//
+// Example:
+//
+//	bytes, err := m.RenderPDF(ctx, url,
+//		mischief.WithPrintBackground(),
+//	)
+func WithPrintBackground() PDFOpt {
+	return func(o *PDFOptions) {
+		o.PrintBackground = true
+	}
+}
+
+// WithHeaderFooter is an option to set the HTML templates of the header
+// and the footer printed on every page.
+//
+// Templates can use the date, title, url, pageNumber and totalPages classes
+// to inject printing values, an empty template leaves the area blank.
+//
+// Example:
+//
+//	bytes, err := m.RenderPDF(ctx, url,
+//		mischief.WithHeaderFooter(
+//			`<span class="title"></span>`,
+//			`<span class="pageNumber"></span>/<span class="totalPages"></span>`,
+//		),
+//	)
+func WithHeaderFooter(header, footer string) PDFOpt {
+	return func(o *PDFOptions) {
+		o.HeaderTemplate = header
+		o.FooterTemplate = footer
+	}
+}
@@ -0,0 +1,170 @@
+package mischief
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// ResourceType is a type of resource that can be blocked.
+type ResourceType string
+
+const (
+	ResourceImage      ResourceType = "image"
+	ResourceMedia      ResourceType = "media"
+	ResourceFont       ResourceType = "font"
+	ResourceStylesheet ResourceType = "stylesheet"
+)
+
+// ResourceTypes lists every resource type that can be blocked.
+var ResourceTypes = []ResourceType{ResourceImage, ResourceMedia, ResourceFont, ResourceStylesheet}
+
+// protoResourceType returns the resource type understood by the DevTools protocol.
+func (t ResourceType) protoResourceType() proto.NetworkResourceType {
+	switch t {
+	case ResourceImage:
+		return proto.NetworkResourceTypeImage
+	case ResourceMedia:
+		return proto.NetworkResourceTypeMedia
+	case ResourceFont:
+		return proto.NetworkResourceTypeFont
+	default:
+		return proto.NetworkResourceTypeStylesheet
+	}
+}
+
+// blocklist is the bundled list of ad and tracker domains.
+//
+//go:embed blocklist.txt
+var blocklist string
+
+// blockedDomains parses the bundled blocklist once.
+var blockedDomains = sync.OnceValue(func() map[string]struct{} {
+	domains := map[string]struct{}{}
+
+	scanner := bufio.NewScanner(strings.NewReader(blocklist))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		domains[strings.ToLower(line)] = struct{}{}
+	}
+
+	return domains
+})
+
+// isAdOrTracker reports whether the host, or one of its parent domains, is in the bundled blocklist.
+func isAdOrTracker(host string) bool {
+	domains := blockedDomains()
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	for {
+		if _, ok := domains[host]; ok {
+			return true
+		}
+
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok {
+			return false
+		}
+
+		host = parent
+	}
+}
+
+// ResourceBlocking describes the requests of a page that are blocked
+// to speed up its loading and declutter it.
+type ResourceBlocking struct {
+	// Ads blocks the requests to the domains of the bundled ad and tracker blocklist
+	Ads bool `json:"ads,omitempty" description:"Block the requests to known ad and tracker domains"`
+	// ResourceTypes are the types of resources blocked
+	ResourceTypes []ResourceType `json:"resourceTypes,omitempty" description:"Types of resources blocked (image, media, font or stylesheet)"`
+	// URLPatterns are the patterns of the URLs blocked, where * matches any characters and ? a single one
+	URLPatterns []string `json:"urlPatterns,omitempty" description:"Patterns of the URLs blocked, * matching any characters"`
+}
+
+// IsZero reports whether nothing is blocked.
+func (b ResourceBlocking) IsZero() bool {
+	return !b.Ads && len(b.ResourceTypes) == 0 && len(b.URLPatterns) == 0
+}
+
+// Validate checks that the resource types are supported.
+func (b ResourceBlocking) Validate() error {
+	for _, resourceType := range b.ResourceTypes {
+		if !slices.Contains(ResourceTypes, resourceType) {
+			return fmt.Errorf("%w: resource type %q is not supported", ErrInvalidResourceBlocking, resourceType)
+		}
+	}
+
+	for _, pattern := range b.URLPatterns {
+		if pattern == "" {
+			return fmt.Errorf("%w: url pattern cannot be empty", ErrInvalidResourceBlocking)
+		}
+	}
+
+	return nil
+}
+
+// resourceBlocker decides which requests of a page are blocked.
+type resourceBlocker struct {
+	ads      bool
+	types    []proto.NetworkResourceType
+	patterns []*regexp.Regexp
+}
+
+// newResourceBlocker compiles the resource blocking, nil when nothing is blocked.
+func newResourceBlocker(b ResourceBlocking) *resourceBlocker {
+	if b.IsZero() {
+		return nil
+	}
+
+	blocker := &resourceBlocker{ads: b.Ads}
+
+	for _, resourceType := range b.ResourceTypes {
+		blocker.types = append(blocker.types, resourceType.protoResourceType())
+	}
+
+	for _, pattern := range b.URLPatterns {
+		blocker.patterns = append(blocker.patterns, patternToRegexp(pattern))
+	}
+
+	return blocker
+}
+
+// patternToRegexp compiles a URL pattern, where * matches any characters and ? a single one.
+func patternToRegexp(pattern string) *regexp.Regexp {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.ReplaceAll(expression, `\*`, `.*`)
+	expression = strings.ReplaceAll(expression, `\?`, `.`)
+
+	return regexp.MustCompile(`\A` + expression + `\z`)
+}
+
+// blocks reports whether the request is blocked.
+func (b *resourceBlocker) blocks(resourceType proto.NetworkResourceType, u *url.URL) bool {
+	if slices.Contains(b.types, resourceType) {
+		return true
+	}
+
+	if b.ads && isAdOrTracker(u.Hostname()) {
+		return true
+	}
+
+	rawUrl := u.String()
+	for _, pattern := range b.patterns {
+		if pattern.MatchString(rawUrl) {
+			return true
+		}
+	}
+
+	return false
+}
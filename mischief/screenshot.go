@@ -1,61 +1,237 @@
 package mischief
 
 import (
+	"context"
 	"errors"
+	"html"
 	"log/slog"
+	"regexp"
 	"time"
 
+	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
+	"github.com/yyewolf/rodent/metrics"
 )
 
-// TakeScreenshot takes a screenshot of the given URL.
+// Screenshot is a screenshot, along with the outcome of the steps run before it.
+type Screenshot struct {
+	// Data is the image
+	Data []byte
+	// Steps are the results of the interaction steps, in order
+	Steps []StepResult
+}
+
+// TakeScreenshot takes a screenshot of the URL, or the HTML document, of the options.
 // It returns the screenshot as a byte slice.
 //
+// It is Capture without the results of the steps.
+func (mischief *Mischief) TakeScreenshot(ctx context.Context, options ScreenshotOptions) ([]byte, error) {
+	screenshot, err := mischief.Capture(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return screenshot.Data, nil
+}
+
+// Capture takes a screenshot of the URL, or the HTML document, of the options.
+// It returns the screenshot along with the results of the steps.
+//
 // In order :
 //
+// - It checks the URL against the network policy
+//
 // - It gets a browser from the pool
 //
-// - It opens a new page with the given URL
+// - It intercepts the requests of a page to enforce the network policy and send the credentials
+//
+// - It sets the requested headers and cookies on the page
+//
+// - It registers the requested script to evaluate in every new document of the page
+//
+// - It applies the requested viewport to the page
 //
-// - It waits for the page to be stable
+// - It opens the page with the given URL, or sets the HTML document on a blank page
 //
-// - It takes the screenshot
+// - It runs the requested waits in order, or waits for the DOM to be stable
 //
-// It puts the browser back to the pool after returning.
-func (mischief *Mischief) TakeScreenshot(url string) ([]byte, error) {
-	mischief.logger.Info("mischief is taking a screenshot", slog.Any("url", url))
+// - It runs the requested interaction steps in order
+//
+// - It evaluates the requested script
+//
+// - It accepts the cookie banners, hides the requested elements and injects the requested CSS
+//
+// - It waits for the requested element, if any
+//
+// - It takes the screenshot, clipped to the element, the region or the whole document if requested
+//
+// - It discards the screenshot when a response came from an address blocked by the network policy
+//
+// It removes the headers, cookies and credentials, resets the page and puts the browser back to the pool after returning.
+// When the context is cancelled, the screenshot is aborted and the browser released.
+func (mischief *Mischief) Capture(ctx context.Context, options ScreenshotOptions) (_ Screenshot, err error) {
+	defer recordError(&err)
 
-	rat, err := mischief.getRat()
+	err = options.Validate()
 	if err != nil {
-		return nil, errors.Join(ErrGettingBrowser, err)
+		return Screenshot{}, err
 	}
-	defer mischief.ratPool.Put(rat)
 
-	rat.Lock()
-	defer rat.Unlock()
+	for _, target := range []string{options.URL, options.BaseURL} {
+		if target == "" {
+			continue
+		}
 
-	page, err := rat.GetPage()
-	if err != nil {
-		return nil, errors.Join(ErrGettingPage, err)
+		err = mischief.checkURL(ctx, target)
+		if err != nil {
+			return Screenshot{}, err
+		}
 	}
-	defer rat.PutPage(page)
 
-	page = page.Timeout(mischief.pageStabilityTimeout)
+	if options.HTML != "" {
+		mischief.logger.Info("mischief is rendering html", slog.Any("size", len(options.HTML)), slog.Any("baseUrl", options.BaseURL))
 
-	err = page.Navigate(url)
-	if err != nil {
-		return nil, errors.Join(ErrNavigatingToPage, err)
+		document := options.HTML
+		if options.BaseURL != "" {
+			document = withBaseURL(document, options.BaseURL)
+		}
+
+		return mischief.screenshot(ctx, documentContent(document), options)
+	}
+
+	mischief.logger.Info("mischief is taking a screenshot", slog.Any("url", options.URL))
+
+	return mischief.screenshot(ctx, navigateTo(options.URL), options)
+}
+
+// prolog matches the comments and the doctype starting an HTML document.
+var prolog = regexp.MustCompile(`(?is)\A\s*(?:<!--.*?-->\s*)*<!doctype[^>]*>`)
+
+// withBaseURL inserts a base element with the URL in the document, after its
+// doctype so that the document is not switched to quirks mode, and before
+// any element so that it applies to all their URLs.
+func withBaseURL(document string, baseURL string) string {
+	base := `<base href="` + html.EscapeString(baseURL) + `">`
+	end := 0
+	if loc := prolog.FindStringIndex(document); loc != nil {
+		end = loc[1]
 	}
 
-	err = page.WaitDOMStable(time.Millisecond, 0)
+	return document[:end] + base + document[end:]
+}
+
+// screenshot loads the source in a pooled page and captures it.
+func (mischief *Mischief) screenshot(ctx context.Context, source pageSource, options ScreenshotOptions) (Screenshot, error) {
+	var screenshot Screenshot
+
+	err := mischief.withPage(ctx, func(page *rod.Page) error {
+		interception, stop, err := mischief.interceptRequests(page, options)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		if options.hasSession() {
+			restore, err := applySession(page, options)
+			if err != nil {
+				return err
+			}
+			defer restore()
+		}
+
+		if options.EvaluateOnNewDocument != "" {
+			remove, err := evaluateOnNewDocument(page, options.EvaluateOnNewDocument)
+			if err != nil {
+				return err
+			}
+			defer remove()
+		}
+
+		err = mischief.loadPage(page, source, options.Viewport, options.Waits)
+		if err != nil {
+			return interception.explain(err)
+		}
+
+		if len(options.Steps) > 0 {
+			screenshot.Steps, err = mischief.runSteps(page, options.Steps)
+			if err != nil {
+				return err
+			}
+		}
+
+		if options.Script != "" {
+			err = mischief.runScript(page, options.Script, time.Duration(options.ScriptTimeout)*time.Millisecond)
+			if err != nil {
+				return err
+			}
+		}
+
+		if options.hidesElements() {
+			err = mischief.hideElements(page, options)
+			if err != nil {
+				return err
+			}
+		}
+
+		screenshot.Data, err = mischief.captureImage(page, options)
+		if err != nil {
+			return err
+		}
+
+		return interception.verify()
+	})
 	if err != nil {
-		return nil, errors.Join(ErrWaitingForPageToBeStable, err)
+		return Screenshot{}, err
 	}
 
-	page = page.CancelTimeout()
+	return screenshot, nil
+}
+
+// captureImage takes the screenshot of a loaded page.
+func (mischief *Mischief) captureImage(page *rod.Page, options ScreenshotOptions) ([]byte, error) {
+	defer metrics.ObservePhase(metrics.PhaseCapture, time.Now())
 
 	screenshotParams := &proto.PageCaptureScreenshot{
-		Format: proto.PageCaptureScreenshotFormatPng,
+		Format: options.Format.protoFormat(),
+	}
+
+	if options.Quality != 0 {
+		screenshotParams.Quality = &options.Quality
+	}
+
+	switch {
+	case options.Selector != "":
+		timedPage := page.Timeout(mischief.pageStabilityTimeout)
+		clip, err := elementClip(timedPage, options.Selector)
+		timedPage.CancelTimeout()
+
+		if errors.Is(err, ErrWaitingForElement) {
+			return nil, err
+		}
+
+		if err != nil {
+			return nil, errors.Join(ErrWhileTakingScreenshot, err)
+		}
+
+		screenshotParams.Clip = clip
+		screenshotParams.CaptureBeyondViewport = true
+	case options.Clip != nil:
+		screenshotParams.Clip = &proto.PageViewport{
+			X:      options.Clip.X,
+			Y:      options.Clip.Y,
+			Width:  options.Clip.Width,
+			Height: options.Clip.Height,
+			Scale:  1,
+		}
+		screenshotParams.CaptureBeyondViewport = true
+	case options.FullPage:
+		clip, err := fullPageClip(page, mischief.fullPageMaxHeight)
+		if err != nil {
+			return nil, errors.Join(ErrWhileTakingScreenshot, err)
+		}
+
+		screenshotParams.Clip = clip
+		screenshotParams.CaptureBeyondViewport = true
 	}
 
 	bytes, err := page.Screenshot(false, screenshotParams)
@@ -0,0 +1,247 @@
+package mischief
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// maxViewportSize is the maximum width or height accepted for a viewport.
+const maxViewportSize = 16384
+
+// Viewport describes the device metrics emulated on a page
+// before taking a screenshot.
+//
+// A zero Width or Height keeps the browser default for that dimension.
+type Viewport struct {
+	// Width is the width of the viewport in CSS pixels
+	Width int `json:"width,omitempty" description:"Width of the viewport in CSS pixels" example:"1920"`
+	// Height is the height of the viewport in CSS pixels
+	Height int `json:"height,omitempty" description:"Height of the viewport in CSS pixels" example:"1080"`
+	// DeviceScaleFactor is the device pixel ratio, 0 keeps the default
+	DeviceScaleFactor float64 `json:"deviceScaleFactor,omitempty" description:"Device pixel ratio of the viewport"`
+	// Mobile emulates a mobile device (meta viewport, overlay scrollbars, ...)
+	Mobile bool `json:"mobile,omitempty" description:"Emulate a mobile device"`
+	// Touch enables touch events emulation
+	Touch bool `json:"touch,omitempty" description:"Enable touch events emulation"`
+}
+
+// IsZero reports whether the viewport leaves the browser defaults untouched.
+func (v Viewport) IsZero() bool {
+	return v == Viewport{}
+}
+
+// Validate checks that the viewport values are within acceptable bounds.
+func (v Viewport) Validate() error {
+	if v.Width < 0 || v.Width > maxViewportSize {
+		return fmt.Errorf("%w: width must be between 0 and %d", ErrInvalidViewport, maxViewportSize)
+	}
+
+	if v.Height < 0 || v.Height > maxViewportSize {
+		return fmt.Errorf("%w: height must be between 0 and %d", ErrInvalidViewport, maxViewportSize)
+	}
+
+	if v.DeviceScaleFactor < 0 || v.DeviceScaleFactor > 10 {
+		return fmt.Errorf("%w: device scale factor must be between 0 and 10", ErrInvalidViewport)
+	}
+
+	return nil
+}
+
+// Clip is a region of the document to capture, in CSS pixels.
+type Clip struct {
+	X      float64 `json:"x" description:"Horizontal offset from the left of the document"`
+	Y      float64 `json:"y" description:"Vertical offset from the top of the document"`
+	Width  float64 `json:"width" description:"Width of the region" example:"800"`
+	Height float64 `json:"height" description:"Height of the region" example:"600"`
+}
+
+// Validate checks that the clip describes a non-empty region of the document.
+func (c Clip) Validate() error {
+	if c.X < 0 || c.Y < 0 {
+		return fmt.Errorf("%w: offsets must be positive", ErrInvalidClip)
+	}
+
+	if c.Width <= 0 || c.Height <= 0 {
+		return fmt.Errorf("%w: width and height must be strictly positive", ErrInvalidClip)
+	}
+
+	if c.Width > maxViewportSize || c.Height > maxViewportSize {
+		return fmt.Errorf("%w: width and height must be lower than %d", ErrInvalidClip, maxViewportSize)
+	}
+
+	return nil
+}
+
+// ScreenshotOptions holds the options used when taking a screenshot.
+//
+// Either URL or HTML must be set.
+type ScreenshotOptions struct {
+	// URL is the URL of the page to capture
+	URL string `json:"url,omitempty" description:"The website to take a screenshot of" example:"https://google.com"`
+	// HTML is the HTML document to capture instead of a URL
+	HTML string `json:"html,omitempty" description:"HTML document to take a screenshot of instead of a URL"`
+	// BaseURL is the URL against which relative URLs of the HTML document are resolved
+	BaseURL string `json:"baseUrl,omitempty" description:"URL against which relative URLs of the HTML document are resolved"`
+
+	// Headers are extra HTTP headers sent with every request of the page
+	Headers map[string]string `json:"headers,omitempty" description:"Extra HTTP headers sent with every request of the page"`
+	// Cookies are set in the browser before loading the page
+	Cookies []Cookie `json:"cookies,omitempty" description:"Cookies set before loading the page"`
+	// BasicAuth are the credentials sent to the origin of the page
+	BasicAuth *BasicAuth `json:"basicAuth,omitempty" description:"HTTP basic authentication credentials sent to the origin of the page"`
+
+	// Blocking are the requests blocked while loading the page, the default of the mischief when nil
+	Blocking *ResourceBlocking `json:"blocking,omitempty" description:"Requests blocked while loading the page, the server default when omitted"`
+
+	// Waits are run in order after loading the page, the DOM stable wait when empty
+	Waits []Wait `json:"waits,omitempty" description:"Waits run in order before capturing the page, a DOM stable wait when omitted"`
+
+	// Steps are interactions run in order after the waits
+	Steps []Step `json:"steps,omitempty" description:"Interactions with the page run in order after the waits"`
+
+	// EvaluateOnNewDocument is a script evaluated in every document of the page, before its own scripts
+	EvaluateOnNewDocument string `json:"evaluateOnNewDocument,omitempty" description:"Script evaluated in every document of the page, before its own scripts" example:"localStorage.setItem('theme', 'dark')"`
+	// Script is the body of an async function evaluated in the page after the waits
+	Script string `json:"script,omitempty" description:"Body of an async function evaluated in the page after the waits" example:"document.querySelector('details').open = true"`
+	// ScriptTimeout is the timeout of the script in milliseconds, the page stability timeout when 0
+	ScriptTimeout int `json:"scriptTimeout,omitempty" description:"Timeout of the script in milliseconds, the page stability timeout when omitted" example:"5000"`
+
+	// HideCookieBanners accepts and hides the banners of common consent management platforms
+	HideCookieBanners bool `json:"hideCookieBanners,omitempty" description:"Accept and hide the cookie banners of common consent management platforms"`
+	// HideSelectors are CSS selectors of the elements hidden before capturing
+	HideSelectors []string `json:"hideSelectors,omitempty" description:"CSS selectors of the elements hidden before capturing"`
+	// CSS is a style sheet injected in the page before capturing
+	CSS string `json:"css,omitempty" description:"Style sheet injected in the page before capturing" example:"body { background: white; }"`
+
+	// Viewport is the viewport to emulate on the page
+	Viewport Viewport `json:"viewport,omitempty" description:"Viewport emulated on the page"`
+	// FullPage captures the whole scrollable document instead of the viewport
+	FullPage bool `json:"fullPage,omitempty" description:"Capture the whole scrollable document instead of the viewport"`
+	// Selector restricts the capture to the first element matching this CSS selector
+	Selector string `json:"selector,omitempty" description:"CSS selector of the element to capture instead of the viewport" example:"#main"`
+	// Clip restricts the capture to a region of the document
+	Clip *Clip `json:"clip,omitempty" description:"Region of the document to capture instead of the viewport"`
+
+	// Format is the image format of the screenshot, PNG when empty
+	Format Format `json:"format,omitempty" description:"Image format (png, jpeg or webp)" example:"jpeg"`
+	// Quality is the compression quality (1-100) of lossy formats, 0 keeps the browser default
+	Quality int `json:"quality,omitempty" description:"Compression quality (1-100) for jpeg and webp" example:"80"`
+}
+
+// Validate checks that the screenshot options are consistent.
+func (o ScreenshotOptions) Validate() error {
+	if (o.URL == "") == (o.HTML == "") {
+		return fmt.Errorf("%w: either url or html must be set", ErrInvalidSource)
+	}
+
+	if o.BaseURL != "" && o.HTML == "" {
+		return fmt.Errorf("%w: base url can only be set with html", ErrInvalidSource)
+	}
+
+	err := validateHeaders(o.Headers)
+	if err != nil {
+		return err
+	}
+
+	for i, cookie := range o.Cookies {
+		err = cookie.Validate()
+		if err != nil {
+			return fmt.Errorf("cookie #%d: %w", i, err)
+		}
+
+		if cookie.Domain == "" && o.sessionOrigin() == "" {
+			return fmt.Errorf("cookie #%d: %w: domain is required without url or base url", i, ErrInvalidCookie)
+		}
+	}
+
+	if o.BasicAuth != nil {
+		err = o.BasicAuth.Validate()
+		if err != nil {
+			return err
+		}
+
+		if o.sessionOrigin() == "" {
+			return fmt.Errorf("%w: url or base url is required", ErrInvalidBasicAuth)
+		}
+	}
+
+	if o.Blocking != nil {
+		err = o.Blocking.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, step := range o.Steps {
+		err = step.Validate()
+		if err != nil {
+			return fmt.Errorf("step #%d: %w", i, err)
+		}
+	}
+
+	if o.ScriptTimeout < 0 || time.Duration(o.ScriptTimeout)*time.Millisecond > maxWaitDuration {
+		return fmt.Errorf("%w: timeout must be between 0 and %s", ErrInvalidScript, maxWaitDuration)
+	}
+
+	if o.ScriptTimeout != 0 && o.Script == "" {
+		return fmt.Errorf("%w: timeout requires a script", ErrInvalidScript)
+	}
+
+	err = validateHideSelectors(o.HideSelectors)
+	if err != nil {
+		return err
+	}
+
+	err = o.Viewport.Validate()
+	if err != nil {
+		return err
+	}
+
+	for i, wait := range o.Waits {
+		err = wait.Validate()
+		if err != nil {
+			return fmt.Errorf("wait #%d: %w", i, err)
+		}
+	}
+
+	if o.Clip != nil {
+		err = o.Clip.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Aliases, such as "jpg", are normalized by ParseFormat and UnmarshalJSON
+	if o.Format != "" && !slices.Contains(Formats, o.Format) {
+		return fmt.Errorf("%w: %q is not supported", ErrInvalidFormat, o.Format)
+	}
+
+	if o.Quality < 0 || o.Quality > 100 {
+		return fmt.Errorf("%w: quality must be between 0 and 100", ErrInvalidQuality)
+	}
+
+	if o.Quality != 0 && !o.Format.SupportsQuality() {
+		return fmt.Errorf("%w: quality is only supported by jpeg and webp", ErrInvalidQuality)
+	}
+
+	return nil
+}
+
+// IsInvalidOptionsError reports whether the error is caused by invalid screenshot options.
+func IsInvalidOptionsError(err error) bool {
+	return errors.Is(err, ErrInvalidSource) ||
+		errors.Is(err, ErrInvalidViewport) ||
+		errors.Is(err, ErrInvalidClip) ||
+		errors.Is(err, ErrInvalidWait) ||
+		errors.Is(err, ErrInvalidFormat) ||
+		errors.Is(err, ErrInvalidQuality) ||
+		errors.Is(err, ErrInvalidHeader) ||
+		errors.Is(err, ErrInvalidCookie) ||
+		errors.Is(err, ErrInvalidBasicAuth) ||
+		errors.Is(err, ErrInvalidResourceBlocking) ||
+		errors.Is(err, ErrInvalidHideSelector) ||
+		errors.Is(err, ErrInvalidScript) ||
+		errors.Is(err, ErrInvalidStep)
+}
@@ -0,0 +1,77 @@
+package mischief
+
+import (
+	"testing"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestWithBaseURL(t *testing.T) {
+	const base = `<base href="https://example.com/assets/">`
+
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{"without doctype", "<p>hello</p>", base + "<p>hello</p>"},
+		{"doctype", "<!DOCTYPE html><p>hello</p>", "<!DOCTYPE html>" + base + "<p>hello</p>"},
+		{"lowercase doctype", "<!doctype html>\n<html>", "<!doctype html>" + base + "\n<html>"},
+		{"legacy doctype", `<!DOCTYPE html PUBLIC "-//W3C//DTD HTML 4.01//EN"><p>`, `<!DOCTYPE html PUBLIC "-//W3C//DTD HTML 4.01//EN">` + base + "<p>"},
+		{"comment and whitespace", "\n<!-- generated -->\n<!DOCTYPE html><p>", "\n<!-- generated -->\n<!DOCTYPE html>" + base + "<p>"},
+		{"doctype later in the document", "<p><!DOCTYPE html></p>", base + "<p><!DOCTYPE html></p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := withBaseURL(tt.document, "https://example.com/assets/")
+			if got != tt.want {
+				t.Errorf("withBaseURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithBaseURLKeepsStandardsMode(t *testing.T) {
+	bin, found := launcher.LookPath()
+	if !found {
+		t.Skip("no browser found")
+	}
+
+	controlURL, err := launcher.New().Bin(bin).NoSandbox(true).Launch()
+	if err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+
+	browser := rod.New().ControlURL(controlURL)
+	err = browser.Connect()
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer browser.MustClose()
+
+	page, err := browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+
+	err = page.SetDocumentContent(withBaseURL("<!DOCTYPE html><html><head><title>test</title></head><body></body></html>", "https://example.com/assets/"))
+	if err != nil {
+		t.Fatalf("SetDocumentContent() error = %v", err)
+	}
+
+	result, err := page.Eval(`() => [document.compatMode, document.baseURI]`)
+	if err != nil {
+		t.Fatalf("Eval() error = %v", err)
+	}
+
+	if got := result.Value.Arr()[0].Str(); got != "CSS1Compat" {
+		t.Errorf("document.compatMode = %q, want CSS1Compat", got)
+	}
+
+	if got := result.Value.Arr()[1].Str(); got != "https://example.com/assets/" {
+		t.Errorf("document.baseURI = %q, want https://example.com/assets/", got)
+	}
+}
@@ -0,0 +1,121 @@
+package mischief
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+const (
+	// ScriptOption names the script evaluated after the waits
+	ScriptOption = "script"
+	// EvaluateOnNewDocumentOption names the script evaluated before the scripts of the page
+	EvaluateOnNewDocumentOption = "evaluateOnNewDocument"
+)
+
+// ScriptError is the error of a user-supplied script that failed.
+type ScriptError struct {
+	// Option names the failing script, ScriptOption or EvaluateOnNewDocumentOption
+	Option string
+	// Message is the message of the exception thrown by the script
+	Message string
+	// Line is the line of the script where the exception was thrown, 0 when unknown
+	Line int
+	// Column is the column of the script where the exception was thrown, 0 when unknown
+	Column int
+	// Timeout reports whether the script was aborted because it took too long
+	Timeout bool
+	// Err is the error returned when evaluating the script
+	Err error
+}
+
+func (e *ScriptError) Error() string {
+	if e.Timeout {
+		return fmt.Sprintf("%s timed out", e.Option)
+	}
+
+	if e.Line > 0 {
+		return fmt.Sprintf("%s failed at line %d, column %d: %s", e.Option, e.Line, e.Column, e.Message)
+	}
+
+	return fmt.Sprintf("%s failed: %s", e.Option, e.Message)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// newScriptError describes the error of the script evaluated with the given option.
+func newScriptError(option string, err error) error {
+	scriptError := &ScriptError{
+		Option:  option,
+		Message: err.Error(),
+		Err:     err,
+	}
+
+	var evalError *rod.EvalError
+	if errors.As(err, &evalError) && evalError.RuntimeExceptionDetails != nil {
+		details := evalError.RuntimeExceptionDetails
+
+		// The script is the body of a function, its first line is the second one
+		scriptError.Line = details.LineNumber
+		scriptError.Column = details.ColumnNumber + 1
+		scriptError.Message = details.Text
+
+		if details.Exception != nil {
+			description, _, _ := strings.Cut(details.Exception.Description, "\n")
+			if description == "" {
+				description = details.Exception.Value.String()
+			}
+
+			scriptError.Message = description
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		scriptError.Timeout = true
+	}
+
+	return errors.Join(ErrEvaluatingScript, scriptError)
+}
+
+// evaluateOnNewDocument registers the script to be evaluated in every
+// document of the page, before its own scripts.
+//
+// The returned remove function unregisters it, it must be called before
+// the page goes back to the pool. It runs detached from the context of the
+// page so that it still happens when the screenshot is cancelled.
+func evaluateOnNewDocument(page *rod.Page, script string) (func(), error) {
+	detached := page.Context(context.Background())
+
+	remove, err := detached.EvalOnNewDocument(script)
+	if err != nil {
+		return nil, newScriptError(EvaluateOnNewDocumentOption, err)
+	}
+
+	return func() {
+		_ = remove()
+	}, nil
+}
+
+// runScript evaluates the script as the body of an async function in a loaded
+// page, with its own timeout, the page stability timeout when 0.
+func (mischief *Mischief) runScript(page *rod.Page, script string, timeout time.Duration) error {
+	if timeout == 0 {
+		timeout = mischief.pageStabilityTimeout
+	}
+
+	timedPage := page.Timeout(timeout)
+	defer timedPage.CancelTimeout()
+
+	_, err := timedPage.Evaluate(rod.Eval("async () => {\n" + script + "\n}").ByPromise())
+	if err != nil {
+		return newScriptError(ScriptOption, err)
+	}
+
+	return nil
+}
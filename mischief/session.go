@@ -0,0 +1,187 @@
+package mischief
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Cookie is a cookie set in the browser before loading the page.
+type Cookie struct {
+	// Name is the name of the cookie
+	Name string `json:"name" description:"Name of the cookie" example:"session"`
+	// Value is the value of the cookie
+	Value string `json:"value" description:"Value of the cookie"`
+	// Domain is the domain of the cookie, the host of the page when empty
+	Domain string `json:"domain,omitempty" description:"Domain of the cookie, the host of the page when omitted" example:".example.com"`
+	// Path is the path of the cookie, the path of the page when empty
+	Path string `json:"path,omitempty" description:"Path of the cookie" example:"/"`
+	// Expires is the expiry date of the cookie in seconds since epoch, a session cookie when 0
+	Expires int64 `json:"expires,omitempty" description:"Expiry date of the cookie in seconds since epoch, a session cookie when omitted"`
+	// Secure restricts the cookie to secure connections
+	Secure bool `json:"secure,omitempty" description:"Restrict the cookie to secure connections"`
+	// HTTPOnly hides the cookie from scripts
+	HTTPOnly bool `json:"httpOnly,omitempty" description:"Hide the cookie from scripts"`
+}
+
+// Validate checks that the cookie can be set.
+func (c Cookie) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCookie)
+	}
+
+	if strings.ContainsAny(c.Name, "=;, \t\r\n") {
+		return fmt.Errorf("%w: name %q contains invalid characters", ErrInvalidCookie, c.Name)
+	}
+
+	if c.Expires < 0 {
+		return fmt.Errorf("%w: expires must be positive", ErrInvalidCookie)
+	}
+
+	return nil
+}
+
+// BasicAuth holds the credentials of the HTTP basic authentication of the page.
+type BasicAuth struct {
+	Username string `json:"username" description:"Username of the basic authentication"`
+	Password string `json:"password" description:"Password of the basic authentication"`
+}
+
+// Validate checks that the credentials can be sent.
+func (b BasicAuth) Validate() error {
+	if b.Username == "" {
+		return fmt.Errorf("%w: username is required", ErrInvalidBasicAuth)
+	}
+
+	if strings.Contains(b.Username, ":") {
+		return fmt.Errorf("%w: username cannot contain a colon", ErrInvalidBasicAuth)
+	}
+
+	return nil
+}
+
+// header is the value of the Authorization header sending the credentials.
+func (b BasicAuth) header() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(b.Username+":"+b.Password))
+}
+
+// validateHeaders checks that the extra headers can be sent.
+func validateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if name == "" || strings.ContainsAny(name, ": \t\r\n") {
+			return fmt.Errorf("%w: name %q is invalid", ErrInvalidHeader, name)
+		}
+
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: value of %q contains a line break", ErrInvalidHeader, name)
+		}
+	}
+
+	return nil
+}
+
+// sessionOrigin is the URL of the page the session is sent to,
+// empty when an HTML document is rendered without base URL.
+func (o ScreenshotOptions) sessionOrigin() string {
+	if o.URL != "" {
+		return o.URL
+	}
+
+	return o.BaseURL
+}
+
+// hasSession reports whether the options carry headers or cookies.
+func (o ScreenshotOptions) hasSession() bool {
+	return len(o.Headers) > 0 || len(o.Cookies) > 0
+}
+
+// applySession sets the extra headers and the cookies of the options
+// on the page before it is loaded, the basic authentication being sent
+// by the interception of the requests.
+//
+// The returned restore function removes them from the page, it must be called
+// before the page goes back to the pool so that they do not leak to the next
+// screenshot. It runs detached from the context of the page so that it still
+// happens when the screenshot is cancelled.
+func applySession(page *rod.Page, options ScreenshotOptions) (restore func(), err error) {
+	detached := page.Context(context.Background())
+	var restorers []func()
+
+	restore = func() {
+		for i := len(restorers) - 1; i >= 0; i-- {
+			restorers[i]()
+		}
+	}
+
+	if len(options.Headers) > 0 {
+		var dict []string
+		for name, value := range options.Headers {
+			dict = append(dict, textproto.CanonicalMIMEHeaderKey(name), value)
+		}
+
+		disable, err := detached.SetExtraHeaders(dict)
+		restorers = append(restorers, disable, func() {
+			_ = proto.NetworkSetExtraHTTPHeaders{Headers: proto.NetworkHeaders{}}.Call(detached)
+		})
+		if err != nil {
+			restore()
+			return nil, errors.Join(ErrApplyingSession, err)
+		}
+	}
+
+	if len(options.Cookies) > 0 {
+		var params []*proto.NetworkCookieParam
+		for _, cookie := range options.Cookies {
+			params = append(params, cookieParam(cookie, options.sessionOrigin()))
+		}
+
+		// Registered before setting them, some may have been set on failure
+		restorers = append(restorers, func() {
+			for _, param := range params {
+				_ = proto.NetworkDeleteCookies{
+					Name:   param.Name,
+					URL:    param.URL,
+					Domain: param.Domain,
+					Path:   param.Path,
+				}.Call(detached)
+			}
+		})
+
+		err = proto.NetworkSetCookies{Cookies: params}.Call(detached)
+		if err != nil {
+			restore()
+			return nil, errors.Join(ErrApplyingSession, err)
+		}
+	}
+
+	return restore, nil
+}
+
+// cookieParam converts the cookie to its protocol counterpart,
+// scoped to the origin when it has no domain.
+func cookieParam(cookie Cookie, origin string) *proto.NetworkCookieParam {
+	param := &proto.NetworkCookieParam{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Domain:   cookie.Domain,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HTTPOnly: cookie.HTTPOnly,
+	}
+
+	if cookie.Domain == "" {
+		param.URL = origin
+	}
+
+	if cookie.Expires > 0 {
+		param.Expires = proto.TimeSinceEpoch(cookie.Expires)
+	}
+
+	return param
+}
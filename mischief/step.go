@@ -0,0 +1,212 @@
+package mischief
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// StepType is the action of an interaction step.
+type StepType string
+
+const (
+	// StepClick clicks the element matching the step selector
+	StepClick StepType = "click"
+	// StepTypeText types the step text in the element matching the step selector
+	StepTypeText StepType = "type"
+	// StepPress presses the step key, on the element matching the step selector if any
+	StepPress StepType = "press"
+	// StepScroll scrolls the element matching the step selector into view
+	StepScroll StepType = "scroll"
+	// StepHover moves the mouse over the element matching the step selector
+	StepHover StepType = "hover"
+	// StepWait waits for the element matching the step selector to be visible, or for the step duration
+	StepWait StepType = "wait"
+)
+
+// namedKeys are the keys, besides printable characters, that a step can press.
+var namedKeys = map[string]input.Key{
+	"Enter":      input.Enter,
+	"Tab":        input.Tab,
+	"Escape":     input.Escape,
+	"Backspace":  input.Backspace,
+	"Delete":     input.Delete,
+	"Space":      input.Space,
+	"ArrowUp":    input.ArrowUp,
+	"ArrowDown":  input.ArrowDown,
+	"ArrowLeft":  input.ArrowLeft,
+	"ArrowRight": input.ArrowRight,
+	"PageUp":     input.PageUp,
+	"PageDown":   input.PageDown,
+	"Home":       input.Home,
+	"End":        input.End,
+}
+
+// parseKey parses the name of a key, or a single printable ASCII character.
+func parseKey(name string) (input.Key, bool) {
+	if key, ok := namedKeys[name]; ok {
+		return key, true
+	}
+
+	if len(name) == 1 && name[0] >= ' ' && name[0] <= '~' {
+		return input.Key(name[0]), true
+	}
+
+	return 0, false
+}
+
+// Step is an interaction with the page run before capturing it.
+type Step struct {
+	// Type is the action of the step
+	Type StepType `json:"type" description:"Action of the step (click, type, press, scroll, hover or wait)" example:"click"`
+	// Selector is the CSS selector of the element the step acts on
+	Selector string `json:"selector,omitempty" description:"CSS selector of the element the step acts on" example:"#login"`
+	// Text is the text typed by a type step
+	Text string `json:"text,omitempty" description:"Text typed in the element (type)" example:"john.doe"`
+	// Key is the key pressed by a press step, such as Enter or a single character
+	Key string `json:"key,omitempty" description:"Key pressed (press), such as Enter, Tab, Escape, ArrowDown or a single character" example:"Enter"`
+	// Duration is the delay of a wait step without selector, in milliseconds
+	Duration int `json:"duration,omitempty" description:"Delay in milliseconds of a wait without selector (wait)" example:"500"`
+	// Timeout is the timeout of the step in milliseconds, the page stability timeout when 0
+	Timeout int `json:"timeout,omitempty" description:"Timeout of the step in milliseconds, the page stability timeout when omitted" example:"5000"`
+}
+
+// Validate checks that the step is consistent with its type.
+func (s Step) Validate() error {
+	switch s.Type {
+	case StepClick, StepScroll, StepHover:
+		if s.Selector == "" {
+			return fmt.Errorf("%w: %s step requires a selector", ErrInvalidStep, s.Type)
+		}
+	case StepTypeText:
+		if s.Selector == "" {
+			return fmt.Errorf("%w: type step requires a selector", ErrInvalidStep)
+		}
+	case StepPress:
+		if _, ok := parseKey(s.Key); !ok {
+			return fmt.Errorf("%w: key %q is not supported", ErrInvalidStep, s.Key)
+		}
+	case StepWait:
+		if s.Selector == "" && s.Duration == 0 {
+			return fmt.Errorf("%w: wait step requires a selector or a duration", ErrInvalidStep)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidStep, s.Type)
+	}
+
+	if s.Timeout < 0 || s.Duration < 0 {
+		return fmt.Errorf("%w: timeout and duration must be positive", ErrInvalidStep)
+	}
+
+	if time.Duration(s.Timeout)*time.Millisecond > maxWaitDuration || time.Duration(s.Duration)*time.Millisecond > maxWaitDuration {
+		return fmt.Errorf("%w: timeout and duration must be lower than %s", ErrInvalidStep, maxWaitDuration)
+	}
+
+	return nil
+}
+
+// StepError is the error of a step that failed, it names the failing step.
+type StepError struct {
+	// Index is the index of the step in the list of steps
+	Index int
+	// Type is the action of the step
+	Type StepType
+	// Duration is the time spent on the step before it failed
+	Duration time.Duration
+	// Err is the error returned by the step
+	Err error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step #%d (%s) failed after %s: %v", e.Index, e.Type, e.Duration.Round(time.Millisecond), e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// StepResult is the outcome of a step that ran successfully.
+type StepResult struct {
+	// Index is the index of the step in the list of steps
+	Index int
+	// Type is the action of the step
+	Type StepType
+	// Duration is the time spent on the step
+	Duration time.Duration
+}
+
+// runSteps runs the steps in order, each with its own timeout.
+// It returns the results of the steps, up to the failing one.
+func (mischief *Mischief) runSteps(page *rod.Page, steps []Step) ([]StepResult, error) {
+	var results []StepResult
+
+	for i, step := range steps {
+		timeout := mischief.pageStabilityTimeout
+		if step.Timeout > 0 {
+			timeout = time.Duration(step.Timeout) * time.Millisecond
+		}
+
+		start := time.Now()
+
+		timedPage := page.Timeout(timeout)
+		err := runStep(timedPage, step)
+		timedPage.CancelTimeout()
+
+		duration := time.Since(start)
+
+		if err != nil {
+			return results, errors.Join(ErrRunningSteps, &StepError{Index: i, Type: step.Type, Duration: duration, Err: err})
+		}
+
+		mischief.logger.Info("mischief ran step", slog.Any("index", i), slog.Any("type", step.Type), slog.Any("duration", duration))
+
+		results = append(results, StepResult{Index: i, Type: step.Type, Duration: duration})
+	}
+
+	return results, nil
+}
+
+// runStep runs a single step on the page.
+func runStep(page *rod.Page, step Step) error {
+	if step.Type == StepWait && step.Selector == "" {
+		select {
+		case <-time.After(time.Duration(step.Duration) * time.Millisecond):
+			return nil
+		case <-page.GetContext().Done():
+			return page.GetContext().Err()
+		}
+	}
+
+	if step.Type == StepPress && step.Selector == "" {
+		key, _ := parseKey(step.Key)
+		return page.Keyboard.Type(key)
+	}
+
+	element, err := page.Element(step.Selector)
+	if err != nil {
+		return err
+	}
+
+	switch step.Type {
+	case StepClick:
+		return element.Click(proto.InputMouseButtonLeft, 1)
+	case StepTypeText:
+		return element.Input(step.Text)
+	case StepPress:
+		key, _ := parseKey(step.Key)
+		return element.Type(key)
+	case StepScroll:
+		return element.ScrollIntoView()
+	case StepHover:
+		return element.Hover()
+	case StepWait:
+		return element.WaitVisible()
+	}
+
+	return fmt.Errorf("%w: unknown type %q", ErrInvalidStep, step.Type)
+}
@@ -1,33 +1,79 @@
 package mischief
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"time"
 
 	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/cdp"
 	"github.com/go-rod/rod/lib/launcher"
 	"github.com/yyewolf/rodent/pool"
 	"github.com/yyewolf/rodent/rat"
 )
 
-func createBrowser(controlUrl *string) func(*rat.Rat) error {
-	return func(rat *rat.Rat) error {
-		var newControlUrl string
+// controlUrlResolveTimeout is the timeout used when resolving the
+// WebSocket URL of an external browser.
+const controlUrlResolveTimeout = 10 * time.Second
 
-		if controlUrl == nil {
-			uri, err := launcher.New().Bin(os.Getenv("BROWSER_PATH")).Launch()
-			if err != nil {
-				return err
-			}
+// launchBrowser launches a local browser for the rat.
+//
+// Site isolation is disabled so that the frames of a page stay in its
+// process, where their requests are intercepted to enforce the policies.
+func launchBrowser(rat *rat.Rat) error {
+	uri, err := launcher.New().
+		Bin(os.Getenv("BROWSER_PATH")).
+		Set("disable-site-isolation-trials").
+		Set("disable-features", "site-per-process", "IsolateOrigins", "TranslateUI").
+		Launch()
+	if err != nil {
+		return err
+	}
+
+	browser := rod.New().ControlURL(uri)
+
+	err = browser.Connect()
+	if err != nil {
+		return err
+	}
+
+	rat.Browser = browser
+
+	return rat.Initialize()
+}
+
+// connectBrowser returns the functions connecting the rat to, and disconnecting
+// it from, the external browser listening on the control URL.
+//
+// Disconnecting only closes the connection, the external browser keeps running.
+func connectBrowser(controlUrl string) (func(*rat.Rat) error, func(*rat.Rat) error) {
+	var ws *cdp.WebSocket
+
+	connect := func(rat *rat.Rat) error {
+		ctx, cancel := context.WithTimeout(context.Background(), controlUrlResolveTimeout)
+		defer cancel()
+
+		wsUrl, err := resolveControlURL(ctx, controlUrl)
+		if err != nil {
+			return err
+		}
 
-			newControlUrl = uri
-		} else {
-			newControlUrl = *controlUrl
+		ws = &cdp.WebSocket{}
+
+		err = ws.Connect(ctx, wsUrl, nil)
+		if err != nil {
+			return err
 		}
 
-		browser := rod.New().ControlURL(newControlUrl)
+		browser := rod.New().Client(cdp.New().Start(ws))
 
-		err := browser.Connect()
+		err = browser.Connect()
 		if err != nil {
+			_ = ws.Close()
 			return err
 		}
 
@@ -35,10 +81,80 @@ func createBrowser(controlUrl *string) func(*rat.Rat) error {
 
 		return rat.Initialize()
 	}
+
+	disconnect := func(rat *rat.Rat) error {
+		if ws == nil {
+			return nil
+		}
+
+		return ws.Close()
+	}
+
+	return connect, disconnect
+}
+
+// resolveControlURL resolves the WebSocket URL of an external browser.
+//
+// WebSocket URLs are returned as is, HTTP URLs (such as http://host:9222) are resolved
+// through the /json/version endpoint of the DevTools protocol.
+func resolveControlURL(ctx context.Context, controlUrl string) (string, error) {
+	parsedUrl, err := url.Parse(controlUrl)
+	if err != nil {
+		return "", err
+	}
+
+	switch parsedUrl.Scheme {
+	case "ws", "wss":
+		return controlUrl, nil
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("unsupported browser URL scheme %q", parsedUrl.Scheme)
+	}
+
+	versionUrl := *parsedUrl
+	versionUrl.Path = "/json/version"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, versionUrl.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d from %s", res.StatusCode, versionUrl.String())
+	}
+
+	var version struct {
+		WebSocketDebuggerUrl string `json:"webSocketDebuggerUrl"`
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&version)
+	if err != nil {
+		return "", err
+	}
+
+	wsUrl, err := url.Parse(version.WebSocketDebuggerUrl)
+	if err != nil || wsUrl.Path == "" {
+		return "", fmt.Errorf("invalid WebSocket URL %q from %s", version.WebSocketDebuggerUrl, versionUrl.String())
+	}
+
+	// The browser advertises the host it listens on, which is usually
+	// not reachable from here (e.g. 127.0.0.1 in a sidecar container)
+	wsUrl.Host = parsedUrl.Host
+	if parsedUrl.Scheme == "https" {
+		wsUrl.Scheme = "wss"
+	}
+
+	return wsUrl.String(), nil
 }
 
-func (mischief *Mischief) getRat() (*rat.Rat, error) {
-	rat, err := pool.GetFromPoolWithTimeout(mischief.ratPool, mischief.browserRetakeTimeout)
+func (mischief *Mischief) getRat(ctx context.Context) (*rat.Rat, error) {
+	rat, err := pool.GetFromPoolWithTimeout(ctx, mischief.ratPool, mischief.browserRetakeTimeout)
 	if err != nil {
 		return nil, err
 	}
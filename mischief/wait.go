@@ -0,0 +1,206 @@
+package mischief
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// maxWaitDuration is the maximum timeout, or duration, of a wait.
+const maxWaitDuration = time.Minute
+
+// WaitType is the strategy used to wait for a page before capturing it.
+type WaitType string
+
+const (
+	// WaitLoad waits for the load event of the page
+	WaitLoad WaitType = "load"
+	// WaitNetworkIdle waits until no request has been in flight for the wait duration
+	WaitNetworkIdle WaitType = "networkIdle"
+	// WaitDOMStable waits until the DOM changes less than the wait diff during the wait duration
+	WaitDOMStable WaitType = "domStable"
+	// WaitSelector waits for an element matching the wait selector to be visible
+	WaitSelector WaitType = "selector"
+	// WaitExpression waits for the wait JavaScript expression to be truthy
+	WaitExpression WaitType = "expression"
+	// WaitDelay waits for the wait duration
+	WaitDelay WaitType = "delay"
+)
+
+// defaultWaits are the waits used when none is requested.
+var defaultWaits = []Wait{
+	{Type: WaitDOMStable},
+}
+
+// Wait is a step waiting for the page to be ready before capturing it.
+type Wait struct {
+	// Type is the strategy of the wait
+	Type WaitType `json:"type" description:"Strategy of the wait (load, networkIdle, domStable, selector, expression or delay)" example:"networkIdle"`
+	// Timeout is the timeout of the wait in milliseconds, the page stability timeout when 0
+	Timeout int `json:"timeout,omitempty" description:"Timeout of the wait in milliseconds, the page stability timeout when omitted" example:"5000"`
+	// Duration is the idle window of networkIdle, the stability window of domStable and the delay of delay, in milliseconds
+	Duration int `json:"duration,omitempty" description:"Idle window (networkIdle), stability window (domStable) or delay (delay) in milliseconds" example:"500"`
+	// Diff is the ratio of DOM changes under which the DOM is considered stable
+	Diff float64 `json:"diff,omitempty" description:"Ratio of DOM changes under which the DOM is considered stable (domStable)"`
+	// Selector is the CSS selector of the element to wait for
+	Selector string `json:"selector,omitempty" description:"CSS selector of the element to wait for (selector)" example:"#main"`
+	// Expression is the JavaScript expression to wait for
+	Expression string `json:"expression,omitempty" description:"JavaScript expression to wait to be truthy (expression)" example:"window.ready === true"`
+}
+
+// Validate checks that the wait is consistent with its type.
+func (w Wait) Validate() error {
+	switch w.Type {
+	case WaitLoad, WaitNetworkIdle, WaitDOMStable, WaitDelay:
+	case WaitSelector:
+		if w.Selector == "" {
+			return fmt.Errorf("%w: selector wait requires a selector", ErrInvalidWait)
+		}
+	case WaitExpression:
+		if w.Expression == "" {
+			return fmt.Errorf("%w: expression wait requires an expression", ErrInvalidWait)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidWait, w.Type)
+	}
+
+	if w.Timeout < 0 || w.Duration < 0 || w.Diff < 0 {
+		return fmt.Errorf("%w: timeout, duration and diff must be positive", ErrInvalidWait)
+	}
+
+	if time.Duration(w.Timeout)*time.Millisecond > maxWaitDuration || time.Duration(w.Duration)*time.Millisecond > maxWaitDuration {
+		return fmt.Errorf("%w: timeout and duration must be lower than %s", ErrInvalidWait, maxWaitDuration)
+	}
+
+	return nil
+}
+
+// WaitError is the error of a wait that failed, it names the failing wait.
+type WaitError struct {
+	// Index is the index of the wait in the list of waits
+	Index int
+	// Type is the strategy of the wait
+	Type WaitType
+	// Err is the error returned by the wait
+	Err error
+}
+
+func (e *WaitError) Error() string {
+	return fmt.Sprintf("wait #%d (%s) failed: %v", e.Index, e.Type, e.Err)
+}
+
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// defaultIdleDuration is the idle window of a network idle wait without duration.
+const defaultIdleDuration = 500 * time.Millisecond
+
+// trackRequests starts tracking the requests of the page for the network idle
+// waits, so that the requests started before a wait runs, such as the ones
+// sent while the page loads, are waited for too.
+//
+// It must be called before the page is loaded. It returns the idle waits
+// by index of their wait, and the function to stop tracking, which must be
+// called once the waits ran.
+func trackRequests(page *rod.Page, waits []Wait) (map[int]func(), func()) {
+	ctx, cancel := context.WithCancel(page.GetContext())
+	idles := map[int]func(){}
+
+	for i, wait := range waits {
+		if wait.Type != WaitNetworkIdle {
+			continue
+		}
+
+		duration := time.Duration(wait.Duration) * time.Millisecond
+		if duration == 0 {
+			duration = defaultIdleDuration
+		}
+
+		idles[i] = page.Context(ctx).WaitRequestIdle(duration, nil, nil, nil)
+	}
+
+	return idles, cancel
+}
+
+// waitFor runs the waits in order, each with its own timeout,
+// the network idle ones with the idle waits of trackRequests.
+func (mischief *Mischief) waitFor(page *rod.Page, waits []Wait, idles map[int]func()) error {
+	if len(waits) == 0 {
+		waits = defaultWaits
+	}
+
+	for i, wait := range waits {
+		timeout := mischief.pageStabilityTimeout
+		if wait.Timeout > 0 {
+			timeout = time.Duration(wait.Timeout) * time.Millisecond
+		}
+
+		timedPage := page.Timeout(timeout)
+		err := runWait(timedPage, wait, idles[i])
+		timedPage.CancelTimeout()
+
+		if err != nil {
+			return errors.Join(ErrWaitingForPageToBeStable, &WaitError{Index: i, Type: wait.Type, Err: err})
+		}
+	}
+
+	return nil
+}
+
+// runWait runs a single wait on the page, a network idle wait
+// running its idle wait until the context of the page is done.
+func runWait(page *rod.Page, wait Wait, idle func()) error {
+	duration := time.Duration(wait.Duration) * time.Millisecond
+
+	switch wait.Type {
+	case WaitLoad:
+		return page.WaitLoad()
+	case WaitNetworkIdle:
+		if idle == nil {
+			return fmt.Errorf("%w: requests of the network idle wait are not tracked", ErrInvalidWait)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			idle()
+			close(done)
+		}()
+
+		// The idle wait is not bound to the timeout of the page, it keeps
+		// running on timeout until the requests stop being tracked
+		select {
+		case <-done:
+		case <-page.GetContext().Done():
+		}
+
+		return page.GetContext().Err()
+	case WaitDOMStable:
+		if duration == 0 {
+			duration = time.Millisecond
+		}
+
+		return page.WaitDOMStable(duration, wait.Diff)
+	case WaitSelector:
+		element, err := page.Element(wait.Selector)
+		if err != nil {
+			return err
+		}
+
+		return element.WaitVisible()
+	case WaitExpression:
+		return page.Wait(rod.Eval(`() => !!(` + wait.Expression + `)`))
+	case WaitDelay:
+		select {
+		case <-time.After(duration):
+			return nil
+		case <-page.GetContext().Done():
+			return page.GetContext().Err()
+		}
+	}
+
+	return fmt.Errorf("%w: unknown type %q", ErrInvalidWait, wait.Type)
+}
@@ -1,17 +1,22 @@
 package pool
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"github.com/go-rod/rod"
 )
 
-func GetFromPoolWithTimeout[K any](pool rod.Pool[K], timeout time.Duration) (*K, error) {
+// GetFromPoolWithTimeout takes an element from the pool, waiting at most for
+// the timeout or until the context is done.
+func GetFromPoolWithTimeout[K any](ctx context.Context, pool rod.Pool[K], timeout time.Duration) (*K, error) {
 	select {
 	case elem := <-pool:
 		return elem, nil
 	case <-time.After(timeout):
 		return nil, errors.New("timeout")
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 }
@@ -1,6 +1,8 @@
 package rat
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -10,17 +12,29 @@ import (
 	"github.com/yyewolf/rodent/pool"
 )
 
+var (
+	ErrCreatingPage = errors.New("error when creating page")
+)
+
 type Rat struct {
 	pagePoolLength    int
 	pageRetakeTimeout time.Duration
+	createAttempts    int
 	createBrowserFunc func(*Rat) error
+	closeBrowserFunc  func(*Rat) error
+
+	// isolatedPages creates every page in its own incognito browser context
+	isolatedPages bool
 
 	CreatedAt time.Time
 
 	pagePool rod.Pool[rod.Page]
 
 	*rod.Browser
-	sync.Mutex
+
+	// RWMutex guards the lifecycle of the browser: pages are used under
+	// the read lock, concurrently, while Close and Recreate need the write lock.
+	sync.RWMutex
 }
 
 type RatOpt func(*Rat)
@@ -33,6 +47,8 @@ type RatOpt func(*Rat)
 //	rat, err := rat.New(
 //		rat.WithPagePoolLength(10),
 //		rat.WithPageRetakeTimeout(5*time.Second),
+//		rat.WithCreateAttempts(3),
+//		rat.WithIsolatedPages(false),
 //	)
 func New(opts ...RatOpt) (*Rat, error) {
 	rat := &Rat{
@@ -42,6 +58,8 @@ func New(opts ...RatOpt) (*Rat, error) {
 	var defaultOpts = []RatOpt{
 		WithPagePoolLength(10),
 		WithPageRetakeTimeout(5 * time.Second),
+		WithCreateAttempts(3),
+		WithCloseBrowserFunc(closeBrowser),
 	}
 
 	opts = append(defaultOpts, opts...)
@@ -54,7 +72,7 @@ func New(opts ...RatOpt) (*Rat, error) {
 		return nil, fmt.Errorf("create browser function is required")
 	}
 
-	err := rat.createBrowserFunc(rat)
+	err := rat.createBrowser()
 	if err != nil {
 		return nil, err
 	}
@@ -67,39 +85,80 @@ func (rat *Rat) Initialize() error {
 	return nil
 }
 
-func (rat *Rat) Close() error {
-	pages, err := rat.Pages()
-	if err != nil {
-		return err
+// createBrowser creates the browser, retrying with a linear backoff
+// so that a browser which is still starting has time to come up.
+func (rat *Rat) createBrowser() error {
+	for attempt := 1; ; attempt++ {
+		err := rat.createBrowserFunc(rat)
+		if err == nil || attempt >= rat.createAttempts {
+			return err
+		}
+
+		time.Sleep(time.Duration(attempt) * time.Second)
 	}
+}
+
+// closeBrowser is the default function closing the browser of a rat.
+func closeBrowser(rat *Rat) error {
+	return rat.Browser.Close()
+}
+
+// Close closes the pages of the browser, then the browser itself.
+//
+// The browser is closed even if its pages could not be listed.
+func (rat *Rat) Close() error {
+	pages, pagesErr := rat.Pages()
 
 	for _, page := range pages {
 		_ = page.Close()
 	}
 
-	err = rat.Browser.Close()
+	err := rat.closeBrowserFunc(rat)
 	if err != nil {
-		return err
+		return errors.Join(pagesErr, err)
 	}
 
-	return nil
+	return pagesErr
 }
 
+// Recreate closes the browser and creates a new one.
+//
+// The browser is recreated even if it could not be closed,
+// as it is usually the case when the connection to the browser was lost.
 func (rat *Rat) Recreate() error {
-	err := rat.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close rat: %w", err)
+	closeErr := rat.Close()
+	if closeErr != nil {
+		closeErr = fmt.Errorf("failed to close rat: %w", closeErr)
 	}
 
-	err = rat.createBrowserFunc(rat)
+	err := rat.createBrowser()
 	if err != nil {
-		return fmt.Errorf("failed to recreate browser: %w", err)
+		return errors.Join(closeErr, fmt.Errorf("failed to recreate browser: %w", err))
 	}
 
 	return nil
 }
 
+// Reconnect recreates the browser, unless it was already replaced since
+// the stale browser was observed by the caller.
+//
+// It takes the write lock, the caller must not hold any lock on the rat.
+func (rat *Rat) Reconnect(stale *rod.Browser) error {
+	rat.Lock()
+	defer rat.Unlock()
+
+	if rat.Browser != stale {
+		return nil
+	}
+
+	return rat.Recreate()
+}
+
 func (rat *Rat) createPageFunc() (*rod.Page, error) {
+	if rat.isolatedPages {
+		return rat.createIsolatedPage()
+	}
+
 	page, err := rat.Browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
 	if err != nil {
 		return nil, err
@@ -108,19 +167,67 @@ func (rat *Rat) createPageFunc() (*rod.Page, error) {
 	return page, nil
 }
 
-func (rat *Rat) GetPage() (*rod.Page, error) {
-	page, err := pool.GetFromPoolWithTimeout(rat.pagePool, rat.pageRetakeTimeout)
+// createIsolatedPage creates a page in a new incognito browser context,
+// which shares no cookies, storage or cache with the other pages.
+func (rat *Rat) createIsolatedPage() (*rod.Page, error) {
+	incognito, err := rat.Browser.Incognito()
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := incognito.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		_ = incognito.Close()
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// GetPage takes a page from the page pool, creating it if needed.
+//
+// With isolated pages, a new page is created in its own browser context
+// every time, the pool only bounds the number of pages open at once.
+//
+// The context only bounds the wait for a free page, the returned page
+// is not bound to it so that it can safely be put back in the pool.
+func (rat *Rat) GetPage(ctx context.Context) (*rod.Page, error) {
+	page, err := pool.GetFromPoolWithTimeout(ctx, rat.pagePool, rat.pageRetakeTimeout)
 	if err != nil {
 		return nil, err
 	}
 
 	if page == nil {
-		return rat.createPageFunc()
+		page, err = rat.createPageFunc()
+		if err != nil {
+			// Give the slot back to the pool so that it does not shrink
+			rat.pagePool.Put(nil)
+			return nil, errors.Join(ErrCreatingPage, err)
+		}
 	}
 
 	return page, nil
 }
 
-func (rat *Rat) PutPage(page *rod.Page) {
-	rat.pagePool.Put(page)
+// PutPage puts the page back in the page pool.
+//
+// With isolated pages, the page and its browser context are closed instead,
+// and the slot is given back to the pool.
+func (rat *Rat) PutPage(page *rod.Page) error {
+	if !rat.isolatedPages {
+		rat.pagePool.Put(page)
+		return nil
+	}
+
+	defer rat.pagePool.Put(nil)
+
+	closeErr := page.Close()
+
+	// Disposing the context also closes the page if it could not be closed
+	err := page.Browser().Close()
+	if err != nil {
+		return errors.Join(closeErr, err)
+	}
+
+	return nil
 }
@@ -54,3 +54,56 @@ func WithCreateBrowserFunc(f func(*Rat) error) RatOpt {
 		rat.createBrowserFunc = f
 	}
 }
+
+// WithCloseBrowserFunc is an option to set the function that closes the browser
+// when closing or recreating a Rat instance.
+//
+// By default, the browser is closed with rat.Browser.Close().
+//
+// Example:
+//
+//	rat, err := rat.New(
+//		rat.WithCloseBrowserFunc(func(rat *rat.Rat) error {
+//			return rat.Browser.Close()
+//		}),
+//	)
+func WithCloseBrowserFunc(f func(*Rat) error) RatOpt {
+	return func(rat *Rat) {
+		rat.closeBrowserFunc = f
+	}
+}
+
+// WithCreateAttempts is an option to set the number of attempts
+// made to create the browser, with a linear backoff between them.
+//
+// By default, this is set to 3.
+//
+// Example:
+//
+//	rat, err := rat.New(
+//		rat.WithCreateAttempts(5),
+//	)
+func WithCreateAttempts(attempts int) RatOpt {
+	return func(rat *Rat) {
+		rat.createAttempts = attempts
+	}
+}
+
+// WithIsolatedPages is an option to create every page in its own
+// incognito browser context, closed when the page is put back.
+//
+// Pages then share no cookies, storage or cache with each other,
+// at the cost of creating a page for every use.
+//
+// By default, this is set to false.
+//
+// Example:
+//
+//	rat, err := rat.New(
+//		rat.WithIsolatedPages(true),
+//	)
+func WithIsolatedPages(isolated bool) RatOpt {
+	return func(rat *Rat) {
+		rat.isolatedPages = isolated
+	}
+}
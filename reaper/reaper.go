@@ -7,6 +7,8 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+
+	"github.com/yyewolf/rodent/metrics"
 )
 
 var (
@@ -55,6 +57,8 @@ func (r *Reaper) Start() {
 						break
 					}
 
+					metrics.ReapedProcesses.Inc()
+
 					// Convert RAM usage to MB for easier reading
 					ramUsage := fmt.Sprintf("%.2f MB", float64(rusage.Maxrss)/1024)
 					r.logger.Info("Reaped child process", slog.Any("pid", pid), slog.Any("freed ram usage", ramUsage))
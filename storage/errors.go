@@ -0,0 +1,14 @@
+package storage
+
+import "errors"
+
+var (
+	ErrInvalidKey          = errors.New("invalid object key")
+	ErrCreatingStorage     = errors.New("error when creating storage")
+	ErrStoringObject       = errors.New("error when storing object")
+	ErrMissingBucket       = errors.New("bucket is required")
+	ErrMissingDirectory    = errors.New("directory is required")
+	ErrMissingPublicURL    = errors.New("public URL is required")
+	ErrInvalidPublicURL    = errors.New("invalid public URL")
+	ErrPresigningObjectURL = errors.New("error when presigning object URL")
+)
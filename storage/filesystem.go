@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// Filesystem stores the objects as files of a local directory,
+// served by a web server or reverse proxy at a public URL.
+type Filesystem struct {
+	// directory is the absolute path of the directory holding the objects
+	directory string
+	// publicURL is the URL the directory is served at
+	publicURL *url.URL
+}
+
+// NewFilesystem creates a new Filesystem storage writing in the directory,
+// it is created if it does not exist.
+//
+// The public URL, where the directory is served, is required: the paths of
+// the files mean nothing to the clients and would disclose the server's.
+//
+// Example:
+//
+//	s, err := storage.NewFilesystem("/var/lib/rodent", "https://static.example.com/rodent")
+func NewFilesystem(directory string, publicURL string) (*Filesystem, error) {
+	if directory == "" {
+		return nil, ErrMissingDirectory
+	}
+
+	if publicURL == "" {
+		return nil, ErrMissingPublicURL
+	}
+
+	parsedPublicURL, err := parsePublicURL(publicURL)
+	if err != nil {
+		return nil, err
+	}
+
+	directory, err = filepath.Abs(directory)
+	if err != nil {
+		return nil, errors.Join(ErrCreatingStorage, err)
+	}
+
+	err = os.MkdirAll(directory, 0o755)
+	if err != nil {
+		return nil, errors.Join(ErrCreatingStorage, err)
+	}
+
+	return &Filesystem{directory: directory, publicURL: parsedPublicURL}, nil
+}
+
+// Put writes the data in the file of the key, replacing it atomically.
+func (f *Filesystem) Put(ctx context.Context, key string, data []byte, contentType string) (Object, error) {
+	err := validateKey(key)
+	if err != nil {
+		return Object{}, err
+	}
+
+	name := filepath.Join(f.directory, filepath.FromSlash(key))
+
+	err = os.MkdirAll(filepath.Dir(name), 0o755)
+	if err != nil {
+		return Object{}, errors.Join(ErrStoringObject, err)
+	}
+
+	// The data is written aside then renamed, readers never see a partial file
+	file, err := os.CreateTemp(filepath.Dir(name), ".rodent-*")
+	if err != nil {
+		return Object{}, errors.Join(ErrStoringObject, err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Object{}, errors.Join(ErrStoringObject, err)
+	}
+
+	err = os.Chmod(file.Name(), 0o644)
+	if err != nil {
+		return Object{}, errors.Join(ErrStoringObject, err)
+	}
+
+	err = os.Rename(file.Name(), name)
+	if err != nil {
+		return Object{}, errors.Join(ErrStoringObject, err)
+	}
+
+	return Object{
+		Key:         key,
+		URL:         f.publicURL.JoinPath(key).String(),
+		ContentType: contentType,
+		Size:        len(data),
+	}, nil
+}
+
+// parsePublicURL parses the URL objects are served at.
+func parsePublicURL(publicURL string) (*url.URL, error) {
+	u, err := url.Parse(publicURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPublicURL, publicURL)
+	}
+
+	return u, nil
+}
+
+var _ Storage = &Filesystem{}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewFilesystem(t *testing.T) {
+	tests := []struct {
+		name      string
+		directory string
+		publicURL string
+		wantErr   error
+	}{
+		{"missing directory", "", "https://static.example.com", ErrMissingDirectory},
+		{"missing public URL", "objects", "", ErrMissingPublicURL},
+		{"file URL", "objects", "file:///var/lib/rodent", ErrInvalidPublicURL},
+		{"relative URL", "objects", "/rodent", ErrInvalidPublicURL},
+		{"valid", "objects", "https://static.example.com/rodent", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directory := tt.directory
+			if directory != "" {
+				directory = filepath.Join(t.TempDir(), directory)
+			}
+
+			_, err := NewFilesystem(directory, tt.publicURL)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewFilesystem() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if _, statErr := os.Stat(directory); tt.wantErr == nil && statErr != nil {
+				t.Errorf("directory not created: %v", statErr)
+			}
+		})
+	}
+}
+
+func TestFilesystemPut(t *testing.T) {
+	root := t.TempDir()
+	directory := filepath.Join(root, "objects")
+
+	f, err := NewFilesystem(directory, "https://static.example.com/rodent/")
+	if err != nil {
+		t.Fatalf("NewFilesystem() error = %v", err)
+	}
+
+	data := []byte("image")
+
+	object, err := f.Put(context.Background(), "2026/10/18/capture.png", data, "image/png")
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	want := Object{
+		Key:         "2026/10/18/capture.png",
+		URL:         "https://static.example.com/rodent/2026/10/18/capture.png",
+		ContentType: "image/png",
+		Size:        len(data),
+	}
+	if object != want {
+		t.Errorf("Put() = %+v, want %+v", object, want)
+	}
+
+	written, err := os.ReadFile(filepath.Join(directory, "2026", "10", "18", "capture.png"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	if string(written) != string(data) {
+		t.Errorf("file content = %q, want %q", written, data)
+	}
+
+	// Writing the key again replaces the file, without leaving temporary files behind
+	_, err = f.Put(context.Background(), "2026/10/18/capture.png", []byte("other"), "image/png")
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(directory, "2026", "10", "18"))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("%d files in the directory, want 1", len(entries))
+	}
+}
+
+func TestFilesystemPutRejectsEscapingKeys(t *testing.T) {
+	root := t.TempDir()
+	directory := filepath.Join(root, "objects")
+
+	f, err := NewFilesystem(directory, "https://static.example.com")
+	if err != nil {
+		t.Fatalf("NewFilesystem() error = %v", err)
+	}
+
+	for _, key := range []string{"../escaped.png", "a/../../escaped.png", "/escaped.png", filepath.Join(root, "escaped.png"), "."} {
+		t.Run(key, func(t *testing.T) {
+			_, err := f.Put(context.Background(), key, []byte("image"), "image/png")
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Put(%q) error = %v, want %v", key, err, ErrInvalidKey)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Name() != "objects" {
+		t.Errorf("files written next to the directory: %v", entries)
+	}
+}
@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// S3 stores the objects in a bucket of an S3-compatible service,
+// such as AWS S3 or MinIO.
+type S3 struct {
+	// client is the client of the service
+	client *minio.Client
+	// bucket is the bucket holding the objects
+	bucket string
+	// prefix is prepended to the keys of the objects
+	prefix string
+
+	// accessKey and secretKey are the credentials, read from the environment when empty
+	accessKey string
+	secretKey string
+	// region is the region of the bucket, detected when empty
+	region string
+	// insecure disables TLS, for local services
+	insecure bool
+
+	// rawPublicURL is the URL the bucket is served at, presigned URLs are returned when empty
+	rawPublicURL string
+	// publicURL is the parsed rawPublicURL
+	publicURL *url.URL
+	// presignExpiry is the validity of the presigned URLs
+	presignExpiry time.Duration
+}
+
+type S3Opt func(*S3)
+
+// NewS3 creates a new S3 storage writing in the bucket of the service at the endpoint.
+//
+// Example (and default values):
+//
+//	s, err := storage.NewS3("s3.amazonaws.com", "screenshots",
+//		storage.WithS3Credentials("access-key", "secret-key"),
+//		storage.WithS3PresignExpiry(time.Hour),
+//	)
+func NewS3(endpoint string, bucket string, opts ...S3Opt) (*S3, error) {
+	if bucket == "" {
+		return nil, ErrMissingBucket
+	}
+
+	err := s3utils.CheckValidBucketName(bucket)
+	if err != nil {
+		return nil, errors.Join(ErrCreatingStorage, err)
+	}
+
+	s3 := &S3{
+		bucket:        bucket,
+		presignExpiry: time.Hour,
+	}
+
+	for _, opt := range opts {
+		opt(s3)
+	}
+
+	// Credentials are read from the AWS or MinIO environment variables when not set
+	creds := credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.EnvMinio{},
+	})
+	if s3.accessKey != "" {
+		creds = credentials.NewStaticV4(s3.accessKey, s3.secretKey, "")
+	}
+
+	s3.client, err = minio.New(endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: !s3.insecure,
+		Region: s3.region,
+	})
+	if err != nil {
+		return nil, errors.Join(ErrCreatingStorage, err)
+	}
+
+	if s3.rawPublicURL != "" {
+		s3.publicURL, err = parsePublicURL(s3.rawPublicURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s3, nil
+}
+
+// WithS3Credentials is an option to set the access and secret keys of the service.
+//
+// By default, they are read from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY,
+// or MINIO_ACCESS_KEY and MINIO_SECRET_KEY, environment variables.
+//
+// Example:
+//
+//	s, err := storage.NewS3("s3.amazonaws.com", "screenshots",
+//		storage.WithS3Credentials("access-key", "secret-key"),
+//	)
+func WithS3Credentials(accessKey string, secretKey string) S3Opt {
+	return func(s *S3) {
+		s.accessKey = accessKey
+		s.secretKey = secretKey
+	}
+}
+
+// WithS3Region is an option to set the region of the bucket.
+//
+// By default, it is detected from the bucket.
+//
+// Example:
+//
+//	s, err := storage.NewS3("s3.amazonaws.com", "screenshots",
+//		storage.WithS3Region("eu-west-3"),
+//	)
+func WithS3Region(region string) S3Opt {
+	return func(s *S3) {
+		s.region = region
+	}
+}
+
+// WithS3Insecure is an option to connect to the service without TLS,
+// such as a local MinIO instance.
+//
+// Example:
+//
+//	s, err := storage.NewS3("localhost:9000", "screenshots",
+//		storage.WithS3Insecure(true),
+//	)
+func WithS3Insecure(insecure bool) S3Opt {
+	return func(s *S3) {
+		s.insecure = insecure
+	}
+}
+
+// WithS3Prefix is an option to set the prefix of the keys of the objects.
+//
+// Example:
+//
+//	s, err := storage.NewS3("s3.amazonaws.com", "shared",
+//		storage.WithS3Prefix("rodent"),
+//	)
+func WithS3Prefix(prefix string) S3Opt {
+	return func(s *S3) {
+		s.prefix = prefix
+	}
+}
+
+// WithS3PublicURL is an option to set the URL the bucket is served at,
+// such as by a CDN, to return the URLs of the objects below it.
+//
+// By default, presigned URLs are returned.
+//
+// Example:
+//
+//	s, err := storage.NewS3("s3.amazonaws.com", "screenshots",
+//		storage.WithS3PublicURL("https://cdn.example.com"),
+//	)
+func WithS3PublicURL(publicURL string) S3Opt {
+	return func(s *S3) {
+		s.rawPublicURL = publicURL
+	}
+}
+
+// WithS3PresignExpiry is an option to set the validity of the presigned URLs,
+// up to 7 days.
+//
+// By default, this is set to 1 hour.
+//
+// Example:
+//
+//	s, err := storage.NewS3("s3.amazonaws.com", "screenshots",
+//		storage.WithS3PresignExpiry(24*time.Hour),
+//	)
+func WithS3PresignExpiry(expiry time.Duration) S3Opt {
+	return func(s *S3) {
+		s.presignExpiry = expiry
+	}
+}
+
+// Put uploads the data to the object of the key, prefixed by the storage prefix.
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) (Object, error) {
+	err := validateKey(key)
+	if err != nil {
+		return Object{}, err
+	}
+
+	key = path.Join(s.prefix, key)
+
+	_, err = s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return Object{}, errors.Join(ErrStoringObject, err)
+	}
+
+	object := Object{
+		Key:         key,
+		ContentType: contentType,
+		Size:        len(data),
+	}
+
+	if s.publicURL != nil {
+		object.URL = s.publicURL.JoinPath(key).String()
+		return object, nil
+	}
+
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, s.presignExpiry, nil)
+	if err != nil {
+		return Object{}, fmt.Errorf("%w: %w", ErrPresigningObjectURL, err)
+	}
+
+	object.URL = u.String()
+
+	return object, nil
+}
+
+var _ Storage = &S3{}
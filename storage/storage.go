@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Storage stores the captures so that they can be fetched later,
+// instead of being sent back in the response.
+type Storage interface {
+	// Put stores the data under the key and returns where it can be fetched
+	Put(ctx context.Context, key string, data []byte, contentType string) (Object, error)
+}
+
+// Object is a capture written to a storage.
+type Object struct {
+	// Key is the key of the object in the storage
+	Key string `json:"key" description:"Key of the object in the storage" example:"2026/10/18/3f2a6c1e9b0d4e7a8c5f1b2d3e4a5b6c.png"`
+	// URL is where the object can be fetched
+	URL string `json:"url" description:"URL where the object can be fetched" example:"https://bucket.s3.amazonaws.com/2026/10/18/3f2a6c1e9b0d4e7a8c5f1b2d3e4a5b6c.png"`
+	// ContentType is the content type of the object
+	ContentType string `json:"contentType" description:"Content type of the object" example:"image/png"`
+	// Size is the size of the object in bytes
+	Size int `json:"size" description:"Size of the object in bytes" example:"48213"`
+}
+
+// NewKey returns a random key, prefixed by the current date, for an object with the extension.
+//
+// Example:
+//
+//	key, err := storage.NewKey("png") // 2026/10/18/3f2a6c1e9b0d4e7a8c5f1b2d3e4a5b6c.png
+func NewKey(extension string) (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s.%s", time.Now().UTC().Format("2006/01/02"), hex.EncodeToString(b), extension), nil
+}
+
+// validateKey checks that the key is a clean relative path,
+// so that it cannot escape the directory or prefix of a storage.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." || key == "." {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"dated key", "2026/10/18/3f2a6c1e9b0d4e7a8c5f1b2d3e4a5b6c.png", false},
+		{"single file", "capture.png", false},
+		{"dots in the name", "a/..b/c..png", false},
+		{"empty", "", true},
+		{"current directory", ".", true},
+		{"parent directory", "..", true},
+		{"parent prefix", "../capture.png", true},
+		{"traversal in the middle", "2026/../../capture.png", true},
+		{"absolute", "/etc/passwd", true},
+		{"dot segment", "./capture.png", true},
+		{"trailing slash", "2026/", true},
+		{"double slash", "2026//capture.png", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("validateKey(%q) error = %v, want %v", tt.key, err, ErrInvalidKey)
+			}
+		})
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	pattern := regexp.MustCompile(`\A\d{4}/\d{2}/\d{2}/[0-9a-f]{32}\.webp\z`)
+
+	first, err := NewKey("webp")
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+
+	if !pattern.MatchString(first) {
+		t.Errorf("NewKey() = %q, want a dated random key", first)
+	}
+
+	if prefix := time.Now().UTC().Format("2006/01/02/"); first[:len(prefix)] != prefix {
+		t.Errorf("NewKey() = %q, want the prefix %q", first, prefix)
+	}
+
+	if err := validateKey(first); err != nil {
+		t.Errorf("NewKey() = %q is not a valid key: %v", first, err)
+	}
+
+	second, err := NewKey("webp")
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+
+	if first == second {
+		t.Errorf("NewKey() returned %q twice", first)
+	}
+}
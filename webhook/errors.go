@@ -0,0 +1,10 @@
+package webhook
+
+import "errors"
+
+var (
+	ErrMissingSecret      = errors.New("webhook secret is required")
+	ErrInvalidCallbackURL = errors.New("invalid callback URL")
+	ErrDeliveringCallback = errors.New("error when delivering callback")
+	ErrCallbackRejected   = errors.New("callback rejected by the receiver")
+)
@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/yyewolf/rodent/storage"
+)
+
+// Payload is the body of a callback, describing a finished capture.
+type Payload struct {
+	// ID is the identifier of the job of the capture
+	ID string `json:"id"`
+	// Status is the status of the job, succeeded or failed
+	Status string `json:"status"`
+	// Error is the error of the capture when it failed
+	Error string `json:"error,omitempty"`
+	// URL is the URL of the captured page, empty for an HTML document
+	URL string `json:"url,omitempty"`
+	// ContentType is the content type of the image
+	ContentType string `json:"contentType,omitempty"`
+	// Image is the captured image, base64 encoded in JSON, empty when it was stored
+	Image []byte `json:"image,omitempty"`
+	// Object is the object the image was written to, when the capture requested to store it
+	Object *storage.Object `json:"object,omitempty"`
+	// CreatedAt is the date the capture was requested
+	CreatedAt time.Time `json:"createdAt"`
+	// StartedAt is the date the capture started
+	StartedAt time.Time `json:"startedAt"`
+	// FinishedAt is the date the capture finished
+	FinishedAt time.Time `json:"finishedAt"`
+}
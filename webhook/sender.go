@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// Sender delivers signed callbacks, retrying with an exponential
+// backoff until the receiver accepts them.
+type Sender struct {
+	// client is the HTTP client sending the callbacks
+	client *http.Client
+	// secret is the key of the HMAC signature of the callbacks
+	secret []byte
+	// maxAttempts is the number of attempts before a callback is given up
+	maxAttempts int
+	// backoff is the delay before the first retry, doubled after each attempt
+	backoff time.Duration
+	// maxBackoff is the maximum delay between two attempts
+	maxBackoff time.Duration
+	// timeout is the timeout of each attempt
+	timeout time.Duration
+	// networkPolicy decides which hosts the callbacks can be sent to
+	networkPolicy mischief.NetworkPolicy
+	// logger is the logger of the sender
+	logger *slog.Logger
+}
+
+type SenderOpt func(*Sender)
+
+// New creates a new Sender instance, a secret is required.
+//
+// Example (and default values):
+//
+//	s, err := webhook.New(
+//		webhook.WithSecret([]byte("secret")),
+//		webhook.WithMaxAttempts(5),
+//		webhook.WithBackoff(time.Second),
+//		webhook.WithMaxBackoff(time.Minute),
+//		webhook.WithTimeout(10*time.Second),
+//		webhook.WithNetworkPolicy(mischief.NetworkPolicy{}),
+//		webhook.WithLogger(slog.Default()),
+//	)
+func New(opts ...SenderOpt) (*Sender, error) {
+	var sender Sender
+
+	var defaultOpts = []SenderOpt{
+		WithHTTPClient(&http.Client{}),
+		WithMaxAttempts(5),
+		WithBackoff(time.Second),
+		WithMaxBackoff(time.Minute),
+		WithTimeout(10 * time.Second),
+		WithNetworkPolicy(mischief.NetworkPolicy{}),
+		WithLogger(slog.Default()),
+	}
+
+	opts = append(defaultOpts, opts...)
+
+	for _, opt := range opts {
+		opt(&sender)
+	}
+
+	if len(sender.secret) == 0 {
+		return nil, ErrMissingSecret
+	}
+
+	sender.maxAttempts = max(sender.maxAttempts, 1)
+
+	// Redirects would reach hosts that were not checked against the network policy
+	client := *sender.client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	sender.client = &client
+
+	return &sender, nil
+}
+
+// ValidateURL checks that the callback URL is an absolute http or https URL.
+func ValidateURL(callbackURL string) (*url.URL, error) {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCallbackURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%w: scheme must be http or https", ErrInvalidCallbackURL)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("%w: host is missing", ErrInvalidCallbackURL)
+	}
+
+	return u, nil
+}
+
+// Check validates the callback URL and checks it against the network policy,
+// so that a callback that can never be delivered is rejected right away.
+func (s *Sender) Check(ctx context.Context, callbackURL string) error {
+	u, err := ValidateURL(callbackURL)
+	if err != nil {
+		return err
+	}
+
+	return s.networkPolicy.Check(ctx, u)
+}
+
+// Deliver sends the payload to the callback URL, retrying until the receiver
+// answers with a 2xx status, the attempts are exhausted or the context is done.
+//
+// Network errors, 408, 429 and 5xx statuses are retried, other statuses
+// are reported right away as ErrCallbackRejected.
+func (s *Sender) Deliver(ctx context.Context, callbackURL string, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Join(ErrDeliveringCallback, err)
+	}
+
+	backoff := s.backoff
+
+	for attempt := 1; ; attempt++ {
+		retry, err := s.attempt(ctx, callbackURL, body)
+		if err == nil {
+			s.logger.Info("callback delivered", slog.Any("id", payload.ID), slog.Any("attempt", attempt))
+			return nil
+		}
+
+		if !retry || attempt >= s.maxAttempts {
+			return errors.Join(ErrDeliveringCallback, err)
+		}
+
+		s.logger.Warn("callback attempt failed", slog.Any("id", payload.ID), slog.Any("attempt", attempt), slog.Any("retryIn", backoff), slog.Any("error", err))
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(ErrDeliveringCallback, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, s.maxBackoff)
+	}
+}
+
+// attempt sends the body once, it reports whether a failure can be retried.
+func (s *Sender) attempt(ctx context.Context, callbackURL string, body []byte) (bool, error) {
+	// The host is checked on every attempt, as it may resolve elsewhere since the last one
+	err := s.Check(ctx, callbackURL)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "rodent-webhook")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(s.secret, timestamp, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+
+	// The body is drained so that the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("%w: status %d", ErrCallbackRejected, res.StatusCode)
+
+	retry := res.StatusCode == http.StatusRequestTimeout ||
+		res.StatusCode == http.StatusTooManyRequests ||
+		res.StatusCode >= 500
+
+	return retry, err
+}
@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+// WithSecret is an option to set the key of the HMAC signature of the callbacks.
+//
+// Example:
+//
+//	s, err := webhook.New(
+//		webhook.WithSecret([]byte("secret")),
+//	)
+func WithSecret(secret []byte) SenderOpt {
+	return func(s *Sender) {
+		s.secret = secret
+	}
+}
+
+// WithHTTPClient is an option to set the HTTP client sending the callbacks.
+//
+// Redirects are never followed, whatever the client does.
+//
+// By default, a client without timeout is used, each attempt having its own.
+//
+// Example:
+//
+//	s, err := webhook.New(
+//		webhook.WithHTTPClient(server.Client()),
+//	)
+func WithHTTPClient(client *http.Client) SenderOpt {
+	return func(s *Sender) {
+		s.client = client
+	}
+}
+
+// WithMaxAttempts is an option to set the number of attempts
+// before a callback is given up.
+//
+// By default, this is set to 5.
+//
+// Example:
+//
+//	s, err := webhook.New(
+//		webhook.WithMaxAttempts(10),
+//	)
+func WithMaxAttempts(attempts int) SenderOpt {
+	return func(s *Sender) {
+		s.maxAttempts = attempts
+	}
+}
+
+// WithBackoff is an option to set the delay before the first retry,
+// it is doubled after each attempt.
+//
+// By default, this is set to 1 second.
+//
+// Example:
+//
+//	s, err := webhook.New(
+//		webhook.WithBackoff(500*time.Millisecond),
+//	)
+func WithBackoff(backoff time.Duration) SenderOpt {
+	return func(s *Sender) {
+		s.backoff = backoff
+	}
+}
+
+// WithMaxBackoff is an option to set the maximum delay between two attempts.
+//
+// By default, this is set to 1 minute.
+//
+// Example:
+//
+//	s, err := webhook.New(
+//		webhook.WithMaxBackoff(5*time.Minute),
+//	)
+func WithMaxBackoff(backoff time.Duration) SenderOpt {
+	return func(s *Sender) {
+		s.maxBackoff = backoff
+	}
+}
+
+// WithTimeout is an option to set the timeout of each attempt.
+//
+// By default, this is set to 10 seconds.
+//
+// Example:
+//
+//	s, err := webhook.New(
+//		webhook.WithTimeout(30*time.Second),
+//	)
+func WithTimeout(timeout time.Duration) SenderOpt {
+	return func(s *Sender) {
+		s.timeout = timeout
+	}
+}
+
+// WithNetworkPolicy is an option to set which hosts the callbacks can be sent to.
+//
+// By default, private, loopback, link-local and reserved addresses are not
+// reachable. Receivers running locally, such as httptest servers, need them allowed.
+//
+// Example:
+//
+//	s, err := webhook.New(
+//		webhook.WithNetworkPolicy(mischief.NetworkPolicy{AllowPrivateNetworks: true}),
+//	)
+func WithNetworkPolicy(policy mischief.NetworkPolicy) SenderOpt {
+	return func(s *Sender) {
+		s.networkPolicy = policy
+	}
+}
+
+// WithLogger is an option to set the logger of the sender.
+//
+// By default, the logger is set to slog.Default().
+//
+// Example:
+//
+//	s, err := webhook.New(
+//		webhook.WithLogger(slog.Default()),
+//	)
+func WithLogger(logger *slog.Logger) SenderOpt {
+	return func(s *Sender) {
+		s.logger = logger
+	}
+}
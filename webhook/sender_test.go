@@ -0,0 +1,258 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yyewolf/rodent/mischief"
+)
+
+var testSecret = []byte("secret")
+
+// newTestSender creates a sender allowed to reach the local receivers,
+// with a short backoff so that retries are fast.
+func newTestSender(t *testing.T, opts ...SenderOpt) *Sender {
+	t.Helper()
+
+	defaultOpts := []SenderOpt{
+		WithSecret(testSecret),
+		WithBackoff(10 * time.Millisecond),
+		WithMaxBackoff(40 * time.Millisecond),
+		WithTimeout(time.Second),
+		WithNetworkPolicy(mischief.NetworkPolicy{AllowPrivateNetworks: true}),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}
+
+	sender, err := New(append(defaultOpts, opts...)...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return sender
+}
+
+// receiver records the callbacks it receives, answering with the given statuses in turn,
+// the last one being repeated.
+type receiver struct {
+	statuses []int
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	times    []time.Time
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	r.times = append(r.times, time.Now())
+	status := r.statuses[min(len(r.requests), len(r.statuses))-1]
+	r.mu.Unlock()
+
+	w.WriteHeader(status)
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.requests)
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":"job"}`)
+	signature := Sign(testSecret, "1700000000", body)
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		timestamp string
+		signature string
+		body      []byte
+		want      bool
+	}{
+		{"valid", testSecret, "1700000000", signature, body, true},
+		{"other secret", []byte("other"), "1700000000", signature, body, false},
+		{"other timestamp", testSecret, "1700000001", signature, body, false},
+		{"other body", testSecret, "1700000000", signature, []byte(`{"id":"other"}`), false},
+		{"missing prefix", testSecret, "1700000000", signature[len(signaturePrefix):], body, false},
+		{"empty", testSecret, "1700000000", "", body, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Verify(tt.secret, tt.timestamp, tt.signature, tt.body)
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliverSignsCallback(t *testing.T) {
+	recv := &receiver{statuses: []int{http.StatusNoContent}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	err := newTestSender(t).Deliver(context.Background(), server.URL, Payload{ID: "job", Status: "succeeded"})
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	if recv.count() != 1 {
+		t.Fatalf("receiver got %d requests, want 1", recv.count())
+	}
+
+	req, body := recv.requests[0], recv.bodies[0]
+	if !Verify(testSecret, req.Header.Get(TimestampHeader), req.Header.Get(SignatureHeader), body) {
+		t.Errorf("signature %q does not match the body", req.Header.Get(SignatureHeader))
+	}
+
+	if got := req.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		attempts  int
+		wantErr   error
+		wantCalls int
+	}{
+		{"server errors then success", []int{500, 503, 200}, 5, nil, 3},
+		{"too many requests then success", []int{429, 200}, 5, nil, 2},
+		{"attempts exhausted", []int{502, 502, 502, 502}, 4, ErrCallbackRejected, 4},
+		{"client error not retried", []int{400}, 5, ErrCallbackRejected, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recv := &receiver{statuses: tt.statuses}
+			server := httptest.NewServer(recv)
+			defer server.Close()
+
+			err := newTestSender(t, WithMaxAttempts(tt.attempts)).Deliver(context.Background(), server.URL, Payload{ID: "job"})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Deliver() error = %v", err)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Deliver() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if recv.count() != tt.wantCalls {
+				t.Fatalf("receiver got %d requests, want %d", recv.count(), tt.wantCalls)
+			}
+
+			// The delay doubles after every attempt, up to the maximum backoff
+			backoff := 10 * time.Millisecond
+			for i := 1; i < len(recv.times); i++ {
+				if delay := recv.times[i].Sub(recv.times[i-1]); delay < backoff {
+					t.Errorf("attempt %d came %v after the previous one, want at least %v", i+1, delay, backoff)
+				}
+
+				backoff = min(backoff*2, 40*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestDeliverStopsWithContext(t *testing.T) {
+	recv := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	sender := newTestSender(t, WithMaxAttempts(100), WithBackoff(time.Second))
+
+	err := sender.Deliver(ctx, server.URL, Payload{ID: "job"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Deliver() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if recv.count() != 1 {
+		t.Errorf("receiver got %d requests, want 1", recv.count())
+	}
+}
+
+func TestDeliverRefusesRedirects(t *testing.T) {
+	target := &receiver{statuses: []int{http.StatusOK}}
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+
+	redirectServer := httptest.NewServer(http.RedirectHandler(targetServer.URL, http.StatusTemporaryRedirect))
+	defer redirectServer.Close()
+
+	err := newTestSender(t).Deliver(context.Background(), redirectServer.URL, Payload{ID: "job"})
+	if !errors.Is(err, ErrCallbackRejected) {
+		t.Fatalf("Deliver() error = %v, want %v", err, ErrCallbackRejected)
+	}
+
+	if target.count() != 0 {
+		t.Errorf("redirect target got %d requests, want 0", target.count())
+	}
+}
+
+func TestDeliverEnforcesNetworkPolicy(t *testing.T) {
+	recv := &receiver{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	// The default policy blocks the loopback address the receiver listens on
+	sender := newTestSender(t, WithNetworkPolicy(mischief.NetworkPolicy{}))
+
+	err := sender.Check(context.Background(), server.URL)
+	if !errors.Is(err, mischief.ErrBlockedByNetworkPolicy) {
+		t.Fatalf("Check() error = %v, want %v", err, mischief.ErrBlockedByNetworkPolicy)
+	}
+
+	err = sender.Deliver(context.Background(), server.URL, Payload{ID: "job"})
+	if !errors.Is(err, mischief.ErrBlockedByNetworkPolicy) {
+		t.Fatalf("Deliver() error = %v, want %v", err, mischief.ErrBlockedByNetworkPolicy)
+	}
+
+	if recv.count() != 0 {
+		t.Errorf("receiver got %d requests, want 0", recv.count())
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"https", "https://example.com/callback", false},
+		{"http", "http://example.com/callback", false},
+		{"other scheme", "ftp://example.com/callback", true},
+		{"relative", "/callback", true},
+		{"missing host", "https:///callback", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrInvalidCallbackURL) {
+				t.Errorf("ValidateURL() error = %v, want %v", err, ErrInvalidCallbackURL)
+			}
+		})
+	}
+}
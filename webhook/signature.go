@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// SignatureHeader is the header holding the signature of a callback
+	SignatureHeader = "X-Rodent-Signature"
+	// TimestampHeader is the header holding the Unix time a callback was signed at
+	TimestampHeader = "X-Rodent-Timestamp"
+
+	// signaturePrefix names the algorithm of the signature
+	signaturePrefix = "sha256="
+)
+
+// Sign returns the signature of a callback body sent at the timestamp,
+// the hex encoded HMAC-SHA256 of "<timestamp>.<body>" prefixed by "sha256=".
+//
+// The timestamp is signed along with the body so that receivers can
+// reject replayed callbacks.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature matches the callback body sent at the timestamp.
+//
+// Example, in the handler of a receiver:
+//
+//	body, _ := io.ReadAll(r.Body)
+//	ok := webhook.Verify(secret, r.Header.Get(webhook.TimestampHeader), r.Header.Get(webhook.SignatureHeader), body)
+func Verify(secret []byte, timestamp string, signature string, body []byte) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}